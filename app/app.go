@@ -19,6 +19,18 @@ var (
 )
 
 func MustClient(ctx context.Context) *wingman.Client {
+	url, options := clientOptions()
+	return wingman.New(url, options...)
+}
+
+// MustRerankService returns the rerank api of the Wingman server, which is
+// not part of wingman.Client.
+func MustRerankService(ctx context.Context) *wingman.RerankService {
+	url, options := clientOptions()
+	return wingman.NewRerankService(append(options, wingman.WithURL(url))...)
+}
+
+func clientOptions() (string, []wingman.RequestOption) {
 	url := os.Getenv("WINGMAN_URL")
 
 	if url == "" {
@@ -31,5 +43,5 @@ func MustClient(ctx context.Context) *wingman.Client {
 		options = append(options, wingman.WithToken(token))
 	}
 
-	return wingman.New(url, options...)
+	return url, options
 }
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/adrianliechti/wingman-cli/pkg/mcp"
+)
+
+var (
+	mcpMu      sync.Mutex
+	mcpClients = map[string]*mcp.Client{}
+	mcpClosed  bool
+)
+
+// MCP returns the client of the MCP servers configured in dir, or nil if
+// there is no config. An empty dir is the working directory. Clients and their
+// sessions are shared by the whole process; call CloseMCP before exiting.
+func MCP(dir string) (*mcp.Client, error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	dir, err := filepath.Abs(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mcpMu.Lock()
+	defer mcpMu.Unlock()
+
+	if mcpClosed {
+		return nil, mcp.ErrClosed
+	}
+
+	if client, ok := mcpClients[dir]; ok {
+		return client, nil
+	}
+
+	client, err := connectMCP(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mcpClients[dir] = client
+
+	return client, nil
+}
+
+// CloseMCP shuts down the sessions of the MCP clients created so far.
+func CloseMCP() error {
+	mcpMu.Lock()
+	defer mcpMu.Unlock()
+
+	// no client is created after closing
+	mcpClosed = true
+
+	var errs []error
+
+	for _, client := range mcpClients {
+		if client == nil {
+			continue
+		}
+
+		errs = append(errs, client.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func connectMCP(dir string) (*mcp.Client, error) {
+	for _, name := range []string{".mcp.json", ".mcp.yaml", "mcp.json", "mcp.yaml"} {
+		path := filepath.Join(dir, name)
+
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+
+		cfg, err := mcp.Parse(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return mcp.New(cfg)
+	}
+
+	return nil, nil
+}
@@ -2,14 +2,12 @@ package app
 
 import (
 	"context"
-	"os"
 
-	"github.com/adrianliechti/wingman-cli/pkg/mcp"
 	"github.com/adrianliechti/wingman-cli/pkg/resource"
 )
 
-func MustConnectResources(ctx context.Context) []resource.Resource {
-	resources, err := ConnectResources(ctx)
+func MustConnectResources(ctx context.Context, dir string) []resource.Resource {
+	resources, err := ConnectResources(ctx, dir)
 
 	if err != nil {
 		panic(err)
@@ -18,26 +16,40 @@ func MustConnectResources(ctx context.Context) []resource.Resource {
 	return resources
 }
 
-func ConnectResources(ctx context.Context) ([]resource.Resource, error) {
-	for _, name := range []string{".mcp.json", ".mcp.yaml", "mcp.json", "mcp.yaml"} {
-		if _, err := os.Stat(name); os.IsNotExist(err) {
-			continue
-		}
+func ConnectResources(ctx context.Context, dir string) ([]resource.Resource, error) {
+	client, err := MCP(dir)
 
-		cfg, err := mcp.Parse(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if client == nil {
+		return nil, nil
+	}
+
+	return client.Resources(ctx)
+}
+
+func MustConnectResourceTemplates(ctx context.Context, dir string) []resource.Template {
+	templates, err := ConnectResourceTemplates(ctx, dir)
 
-		if err != nil {
-			return nil, err
-		}
+	if err != nil {
+		panic(err)
+	}
 
-		mcp, err := mcp.New(cfg)
+	return templates
+}
 
-		if err != nil {
-			return nil, err
-		}
+func ConnectResourceTemplates(ctx context.Context, dir string) ([]resource.Template, error) {
+	client, err := MCP(dir)
+
+	if err != nil {
+		return nil, err
+	}
 
-		return mcp.Resources(ctx)
+	if client == nil {
+		return nil, nil
 	}
 
-	return nil, nil
+	return client.ResourceTemplates(ctx)
 }
@@ -2,9 +2,7 @@ package app
 
 import (
 	"context"
-	"os"
 
-	"github.com/adrianliechti/wingman-cli/pkg/mcp"
 	"github.com/adrianliechti/wingman-cli/pkg/tool"
 )
 
@@ -19,25 +17,15 @@ func MustConnectTools(ctx context.Context) []tool.Tool {
 }
 
 func ConnectTools(ctx context.Context) ([]tool.Tool, error) {
-	for _, name := range []string{".mcp.json", ".mcp.yaml", "mcp.json", "mcp.yaml"} {
-		if _, err := os.Stat(name); os.IsNotExist(err) {
-			continue
-		}
+	client, err := MCP("")
 
-		cfg, err := mcp.Parse(name)
-
-		if err != nil {
-			return nil, err
-		}
-
-		mcp, err := mcp.New(cfg)
-
-		if err != nil {
-			return nil, err
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		return mcp.Tools(ctx)
+	if client == nil {
+		return nil, nil
 	}
 
-	return nil, nil
+	return client.Tools(ctx)
 }
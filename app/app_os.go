@@ -2,6 +2,7 @@ package app
 
 import (
 	"os"
+	"path/filepath"
 )
 
 func MustDir() string {
@@ -17,3 +18,19 @@ func MustDir() string {
 func Dir() (string, error) {
 	return os.Getwd()
 }
+
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	dir = filepath.Join(dir, "wingman")
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
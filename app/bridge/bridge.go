@@ -26,5 +26,5 @@ func Run(ctx context.Context, client *wingman.Client) error {
 
 	cli.Info()
 
-	return bridge.Run(ctx, client, instructions, tools)
+	return bridge.Run(ctx, client, instructions, tools, nil)
 }
@@ -0,0 +1,28 @@
+package index
+
+import (
+	"github.com/adrianliechti/wingman-cli/app/rag"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+func indexPath(options *rag.Options) (string, error) {
+	root, err := rag.RootDir(options)
+
+	if err != nil {
+		return "", err
+	}
+
+	return rag.IndexPath(root, options)
+}
+
+func openIndex(client *wingman.Client, options *rag.Options) (*index.Index, error) {
+	root, err := rag.RootDir(options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rag.OpenExistingIndex(client, root, options)
+}
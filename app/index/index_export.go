@@ -0,0 +1,112 @@
+package index
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/app/rag"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+// exportHeader is the first line of an export and describes the embeddings
+// of the records following it.
+type exportHeader struct {
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+}
+
+type exportRecord struct {
+	Source  string `json:"source"`
+	Content string `json:"content"`
+
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	Embedding []float32 `json:"embedding,omitempty"`
+}
+
+// Export writes the documents of the index along with their vectors as
+// JSONL to path, or to stdout if path is empty or "-".
+func Export(ctx context.Context, client *wingman.Client, path string, options *rag.Options) error {
+	i, err := openIndex(client, options)
+
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+
+	if path != "" && path != "-" {
+		f, err := os.Create(path)
+
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		w = f
+	}
+
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+
+	if err := enc.Encode(exportHeader{
+		Model:      i.Model(),
+		Dimensions: i.Dimensions(),
+	}); err != nil {
+		return err
+	}
+
+	var count int
+	var cursor string
+
+	limit := 100
+
+	for {
+		page, err := i.List(ctx, &index.ListOptions{
+			Limit:  &limit,
+			Cursor: cursor,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		cursor = page.Cursor
+
+		if len(page.Items) == 0 {
+			break
+		}
+
+		for _, d := range page.Items {
+			if err := enc.Encode(exportRecord{
+				Source:  d.Source,
+				Content: d.Content,
+
+				Metadata: d.Metadata,
+
+				Embedding: d.Embedding,
+			}); err != nil {
+				return err
+			}
+
+			count++
+		}
+	}
+
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+
+	if w != os.Stdout {
+		cli.Infof("Exported %d documents to %s", count, path)
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package index
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/app/rag"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+// Import adds the documents of an export, read from path or from stdin if
+// path is "-", to the index. Duplicate documents are skipped, sources whose
+// documents differ from the indexed ones are replaced. Unless reembed is
+// set, the export must have been created with the embedding model of the
+// index; with reembed its vectors are dropped and computed again.
+func Import(ctx context.Context, client *wingman.Client, path string, reembed bool, options *rag.Options) error {
+	if path == "" {
+		return errors.New("file is required")
+	}
+
+	var r io.Reader = os.Stdin
+
+	if path != "-" {
+		f, err := os.Open(path)
+
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		r = f
+	}
+
+	root, err := rag.RootDir(options)
+
+	if err != nil {
+		return err
+	}
+
+	indexPath, err := rag.IndexPath(root, options)
+
+	if err != nil {
+		return err
+	}
+
+	i, err := rag.OpenIndex(client, indexPath, options)
+
+	if err != nil {
+		return err
+	}
+
+	if err := i.CheckModel(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1<<20), 64<<20)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+
+		return errors.New("empty export")
+	}
+
+	var header exportHeader
+
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return fmt.Errorf("invalid export header: %w", err)
+	}
+
+	if header.Model != i.Model() && !reembed {
+		return fmt.Errorf("%w: export was created with %s, the index uses %s; set --embedding-model or use --reembed", index.ErrModelMismatch, modelName(header.Model), modelName(i.Model()))
+	}
+
+	// documents of the export grouped by source, in order of appearance
+	var sources []string
+
+	imported := make(map[string][]index.Document)
+	seen := make(map[string]bool)
+
+	for line := 2; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record exportRecord
+
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		d := index.Document{
+			Source:  record.Source,
+			Content: record.Content,
+
+			Metadata: record.Metadata,
+		}
+
+		if !reembed {
+			d.Embedding = record.Embedding
+		}
+
+		hash := documentHash(d)
+
+		if seen[hash] {
+			continue
+		}
+
+		seen[hash] = true
+
+		if _, ok := imported[d.Source]; !ok {
+			sources = append(sources, d.Source)
+		}
+
+		imported[d.Source] = append(imported[d.Source], d)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// only the collection written to is compared, sources of the others are
+	// never replaced
+	indexed, err := i.WriteSources(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	summary := &rag.Summary{}
+
+	for _, source := range sources {
+		documents := imported[source]
+
+		var existing map[string]bool
+
+		if slices.ContainsFunc(indexed, func(s index.Source) bool { return s.Name == source }) {
+			if existing, err = documentHashes(ctx, i, source); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case existing == nil:
+			if err := i.Index(ctx, documents...); err != nil {
+				return fmt.Errorf("%s: %w", source, err)
+			}
+
+			summary.Added++
+			summary.Chunks += len(documents)
+
+		case sameDocuments(existing, documents):
+			summary.Unchanged++
+
+		default:
+			if err := i.Replace(ctx, source, documents...); err != nil {
+				return fmt.Errorf("%s: %w", source, err)
+			}
+
+			summary.Updated++
+			summary.Chunks += len(documents)
+		}
+	}
+
+	cli.Infof("Imported %s", summary)
+
+	return nil
+}
+
+// documentHashes returns the hashes of the documents of source in the
+// collection written to.
+func documentHashes(ctx context.Context, i *index.Index, source string) (map[string]bool, error) {
+	documents, err := i.WriteDocuments(ctx, source)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(documents))
+
+	for _, d := range documents {
+		result[documentHash(d)] = true
+	}
+
+	return result, nil
+}
+
+func sameDocuments(existing map[string]bool, documents []index.Document) bool {
+	if len(existing) != len(documents) {
+		return false
+	}
+
+	for _, d := range documents {
+		if !existing[documentHash(d)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// documentHash identifies a document by its source, content and metadata.
+func documentHash(d index.Document) string {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%q\x00%q\x00", d.Source, d.Content)
+
+	for _, k := range slices.Sorted(maps.Keys(d.Metadata)) {
+		fmt.Fprintf(h, "%q=%q\x00", k, d.Metadata[k])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
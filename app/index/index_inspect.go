@@ -0,0 +1,60 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/app/rag"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+func Inspect(ctx context.Context, client *wingman.Client, source string, options *rag.Options) error {
+	if source == "" {
+		return errors.New("source is required")
+	}
+
+	i, err := openIndex(client, options)
+
+	if err != nil {
+		return err
+	}
+
+	source = rag.SourceName(source)
+
+	chunks, err := i.Documents(ctx, source)
+
+	if err != nil {
+		return err
+	}
+
+	if len(chunks) == 0 {
+		return errors.New("source not found: " + source)
+	}
+
+	for _, d := range chunks {
+		cli.Title(fmt.Sprintf("%s #%s (id %s)", d.Source, d.Metadata["index"], d.ID))
+
+		var keys []string
+
+		for k := range d.Metadata {
+			keys = append(keys, k)
+		}
+
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			cli.Infof("%s: %s", k, d.Metadata[k])
+		}
+
+		cli.Info()
+		cli.Info(strings.TrimSpace(d.Content))
+		cli.Info()
+	}
+
+	return nil
+}
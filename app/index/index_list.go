@@ -0,0 +1,40 @@
+package index
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/app/rag"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+func List(ctx context.Context, client *wingman.Client, options *rag.Options) error {
+	i, err := openIndex(client, options)
+
+	if err != nil {
+		return err
+	}
+
+	sources, err := i.Sources(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if len(sources) == 0 {
+		cli.Info("Index is empty")
+		return nil
+	}
+
+	var rows [][]string
+
+	for _, s := range sources {
+		rows = append(rows, []string{s.Collection, s.Name, s.Revision, fmt.Sprintf("%d", s.Chunks)})
+	}
+
+	cli.Table([]string{"Collection", "Source", "Revision", "Chunks"}, rows)
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/app/rag"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+func Purge(ctx context.Context, client *wingman.Client, sources []string, options *rag.Options) error {
+	if len(sources) == 0 {
+		return errors.New("at least one source is required")
+	}
+
+	i, err := openIndex(client, options)
+
+	if err != nil {
+		return err
+	}
+
+	existing, err := i.WriteSources(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	sources = slices.Clone(sources)
+
+	for n, s := range sources {
+		s = rag.SourceName(s)
+		sources[n] = s
+
+		found := slices.ContainsFunc(existing, func(e index.Source) bool {
+			return e.Name == s
+		})
+
+		if !found {
+			return errors.New("source not found: " + s)
+		}
+	}
+
+	if err := i.DeleteSources(ctx, sources...); err != nil {
+		return err
+	}
+
+	for _, s := range sources {
+		cli.Infof("Purged %s", s)
+	}
+
+	return nil
+}
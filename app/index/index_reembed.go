@@ -0,0 +1,41 @@
+package index
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/app/rag"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+func Reembed(ctx context.Context, client *wingman.Client, options *rag.Options) error {
+	i, err := openIndex(client, options)
+
+	if err != nil {
+		return err
+	}
+
+	from := i.Model()
+
+	fn := func() error {
+		return i.Reembed(ctx)
+	}
+
+	if err := cli.Run("Re-embedding...", fn); err != nil {
+		return err
+	}
+
+	cli.Infof("Re-embedded index from %s to %s (%d dimensions)", modelName(from), modelName(i.Model()), i.Dimensions())
+
+	return nil
+}
+
+func modelName(model string) string {
+	if model == "" {
+		return "default model"
+	}
+
+	return strconv.Quote(model)
+}
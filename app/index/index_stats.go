@@ -0,0 +1,86 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/app/rag"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+func Stats(ctx context.Context, client *wingman.Client, options *rag.Options) error {
+	i, err := openIndex(client, options)
+
+	if err != nil {
+		return err
+	}
+
+	sources, err := i.Sources(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	collections, err := i.Collections(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	var chunks int
+	var rows [][]string
+
+	for _, s := range sources {
+		chunks += s.Chunks
+		rows = append(rows, []string{s.Collection, s.Name, fmt.Sprintf("%d", s.Chunks)})
+	}
+
+	if len(rows) > 0 {
+		cli.Table([]string{"Collection", "Source", "Chunks"}, rows)
+		cli.Info()
+	}
+
+	cli.Infof("Documents:   %d", len(sources))
+	cli.Infof("Chunks:      %d", chunks)
+	cli.Infof("Collections: %s", strings.Join(collections, ", "))
+	cli.Infof("Model:       %s", modelName(i.Model()))
+	cli.Infof("Dimensions:  %d", i.Dimensions())
+
+	if path, err := indexPath(options); err == nil {
+		cli.Infof("Path:        %s", path)
+		cli.Infof("Size:        %s", formatSize(fileSize(path)))
+	}
+
+	return nil
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return 0
+	}
+
+	return info.Size()
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,36 @@
+package index
+
+import (
+	"context"
+
+	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/app/rag"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+func Vacuum(ctx context.Context, client *wingman.Client, options *rag.Options) error {
+	path, err := indexPath(options)
+
+	if err != nil {
+		return err
+	}
+
+	i, err := openIndex(client, options)
+
+	if err != nil {
+		return err
+	}
+
+	before := fileSize(path)
+
+	if err := cli.Run("Vacuuming...", func() error {
+		return i.Vacuum(ctx)
+	}); err != nil {
+		return err
+	}
+
+	cli.Infof("Vacuumed %s: %s → %s", path, formatSize(before), formatSize(fileSize(path)))
+
+	return nil
+}
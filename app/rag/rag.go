@@ -3,13 +3,11 @@ package rag
 import (
 	"context"
 	_ "embed"
-	"path/filepath"
 
 	"github.com/adrianliechti/go-cli"
 	"github.com/adrianliechti/wingman-cli/app"
 	"github.com/adrianliechti/wingman-cli/pkg/agent"
 	"github.com/adrianliechti/wingman-cli/pkg/index"
-	"github.com/adrianliechti/wingman-cli/pkg/tool/retriever"
 
 	wingman "github.com/adrianliechti/wingman/pkg/client"
 )
@@ -19,12 +17,53 @@ var (
 	DefaultPrompt string
 )
 
-func Run(ctx context.Context, client *wingman.Client, model string) error {
+type Options struct {
+	// Dir is the directory whose index, config and MCP servers are used,
+	// defaults to the working directory
+	Dir string
+
+	// Index is the path of the database, defaults to one per directory in
+	// the user cache dir
+	Index string
+
+	// Collections within the database to use, the first one is indexed
+	Collections []string
+
+	EmbeddingModel string
+
+	BatchSize int
+	NoCache   bool
+
+	Concurrency int
+
+	Watch bool
+
+	// History indexes the git history even if not enabled in the config
+	History bool
+
+	Rerank string
+
+	// Diversity overrides the diversity of the retrieval config
+	Diversity *float64
+
+	Queries int
+	HyDE    bool
+}
+
+func Run(ctx context.Context, client *wingman.Client, model string, options *Options) error {
+	if options == nil {
+		options = new(Options)
+	}
+
 	cli.Info()
 	cli.Info("🤗 Hello, I'm your RAG")
 	cli.Info()
 
-	root := app.MustDir()
+	root, err := RootDir(options)
+
+	if err != nil {
+		return err
+	}
 
 	instructions := app.MustParseInstructions()
 
@@ -32,25 +71,30 @@ func Run(ctx context.Context, client *wingman.Client, model string) error {
 		instructions = DefaultPrompt
 	}
 
-	resources := app.MustConnectResources(ctx)
-
-	index, err := index.New(filepath.Join(root, "wingman.db"), &embeder{client})
+	index, config, _, err := buildIndex(ctx, client, root, options)
 
 	if err != nil {
 		return err
 	}
 
-	if err := IndexDir(ctx, client, index, root); err != nil {
-		return err
-	}
+	if options.Watch {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
 
-	if err := IndexResources(ctx, client, index, resources); err != nil {
-		return err
+		go Watch(ctx, client, index, root, config, DefaultWatchInterval)
+
+		cli.Infof("Watching %s for changes...", root)
 	}
 
 	cli.Info()
 
-	tools, err := retriever.New(index).Tools(ctx)
+	r, err := newRetriever(ctx, client, index, root, config, options)
+
+	if err != nil {
+		return err
+	}
+
+	tools, err := r.Tools(ctx)
 
 	if err != nil {
 		return err
@@ -58,3 +102,84 @@ func Run(ctx context.Context, client *wingman.Client, model string) error {
 
 	return agent.Run(ctx, client, model, instructions, tools)
 }
+
+// buildIndex opens the index of root and brings it up to date with the files
+// below root, the configured MCP resources and, if enabled, the git history.
+// Files and resources that fail to index are reported as warnings and counted
+// in their summary.
+func buildIndex(ctx context.Context, client *wingman.Client, root string, options *Options) (*index.Index, *Config, *Summary, error) {
+	resources := app.MustConnectResources(ctx, root)
+	templates := app.MustConnectResourceTemplates(ctx, root)
+
+	path, err := IndexPath(root, options)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	index, err := OpenIndex(client, path, options)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := index.CheckModel(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	config, err := LoadConfig(root)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if options.Concurrency > 0 {
+		config.Concurrency = options.Concurrency
+	}
+
+	summary, err := IndexDir(ctx, client, index, root, config)
+
+	if summary != nil {
+		cli.Infof("Indexed %s", summary)
+	}
+
+	if err != nil {
+		if summary == nil || ctx.Err() != nil {
+			return nil, nil, nil, err
+		}
+
+		cli.Warn(err)
+	}
+
+	resourceSummary, err := IndexResources(ctx, client, index, config, resources, templates)
+
+	if resourceSummary != nil && (len(resources) > 0 || len(config.Resources) > 0 || resourceSummary.Removed > 0) {
+		cli.Infof("Indexed resources %s", resourceSummary)
+	}
+
+	if err != nil {
+		if resourceSummary == nil || ctx.Err() != nil {
+			return nil, nil, nil, err
+		}
+
+		cli.Warn(err)
+	}
+
+	if config.History.Enabled || options.History {
+		historySummary, err := IndexHistory(ctx, client, index, root, config)
+
+		if historySummary != nil {
+			cli.Infof("Indexed history %s", historySummary)
+		}
+
+		if err != nil {
+			if historySummary == nil || ctx.Err() != nil {
+				return nil, nil, nil, err
+			}
+
+			cli.Warn(err)
+		}
+	}
+
+	return index, config, summary, nil
+}
@@ -0,0 +1,73 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/adrianliechti/wingman-cli/app"
+	"github.com/adrianliechti/wingman-cli/pkg/agent"
+	"github.com/adrianliechti/wingman-cli/pkg/markdown"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+// Ask answers a single question from the index of the directory in the options.
+func Ask(ctx context.Context, client *wingman.Client, model, question string, options *Options) error {
+	question = strings.TrimSpace(question)
+
+	if question == "" {
+		return errors.New("question is required")
+	}
+
+	root, err := RootDir(options)
+
+	if err != nil {
+		return err
+	}
+
+	instructions, err := app.ParseInstructions()
+
+	if err != nil {
+		return err
+	}
+
+	if instructions == "" {
+		instructions = DefaultPrompt
+	}
+
+	i, err := OpenExistingIndex(client, root, options)
+
+	if err != nil {
+		return err
+	}
+
+	config, err := LoadConfig(root)
+
+	if err != nil {
+		return err
+	}
+
+	r, err := newRetriever(ctx, client, i, root, config, options)
+
+	if err != nil {
+		return err
+	}
+
+	tools, err := r.Tools(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	answer, err := agent.Ask(ctx, client, model, instructions, question, tools)
+
+	if err != nil {
+		return err
+	}
+
+	markdown.Render(os.Stdout, answer)
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrianliechti/wingman-cli/pkg/chunk"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+const (
+	ChunkFixed     = "fixed"
+	ChunkMarkdown  = "markdown"
+	ChunkParagraph = "paragraph"
+	ChunkCode      = "code"
+)
+
+const (
+	DefaultChunkSize    = 3000
+	DefaultChunkOverlap = 1500
+)
+
+type Chunking struct {
+	// Strategy is one of "fixed", "markdown", "paragraph" or "code"
+	Strategy string `json:"strategy" yaml:"strategy"`
+
+	// Size and Overlap of the chunks in bytes
+	Size    int  `json:"size" yaml:"size"`
+	Overlap *int `json:"overlap" yaml:"overlap"`
+}
+
+// String identifies the chunking in the metadata of indexed documents, so
+// files are chunked again once it changes.
+func (c Chunking) String() string {
+	return fmt.Sprintf("%s:%d:%d", c.Strategy, c.Size, *c.Overlap)
+}
+
+// matches reports whether records created with the chunking described by
+// indexed would be created the same way by c. Records from before the
+// chunking was recorded used fixed-size segments with the defaults.
+func (c Chunking) matches(indexed string) bool {
+	if indexed == "" {
+		indexed = fmt.Sprintf("%s:%d:%d", ChunkFixed, DefaultChunkSize, DefaultChunkOverlap)
+	}
+
+	return indexed == c.String()
+}
+
+// ChunkingFor resolves the chunking of a file from the settings for its
+// extension, the global settings and the defaults, in that order. Source
+// code defaults to code-aware chunking, everything else to fixed-size
+// segments.
+func (c *Config) ChunkingFor(name string) Chunking {
+	result := c.Chunking.Chunking
+
+	if ext, ok := c.Chunking.Extensions[strings.ToLower(filepath.Ext(name))]; ok {
+		if ext.Strategy != "" {
+			result.Strategy = ext.Strategy
+		}
+
+		if ext.Size > 0 {
+			result.Size = ext.Size
+		}
+
+		if ext.Overlap != nil {
+			result.Overlap = ext.Overlap
+		}
+	}
+
+	if result.Strategy == "" {
+		result.Strategy = ChunkFixed
+
+		if chunk.Language(name) != "" {
+			result.Strategy = ChunkCode
+		}
+	}
+
+	if result.Size <= 0 {
+		result.Size = DefaultChunkSize
+	}
+
+	if result.Overlap == nil {
+		overlap := 0
+
+		if result.Strategy == ChunkFixed {
+			overlap = min(DefaultChunkOverlap, result.Size/2)
+		}
+
+		result.Overlap = &overlap
+	}
+
+	return result
+}
+
+// chunkText splits text, named name, using the given chunking. Fixed-size
+// chunks are created by the segmenter of the server, all others locally.
+func chunkText(ctx context.Context, client *wingman.Client, name, text string, c Chunking) ([]chunk.Chunk, error) {
+	switch c.Strategy {
+	case ChunkFixed:
+		segments, err := retry(ctx, func() ([]wingman.Segment, error) {
+			return client.Segments.New(ctx, wingman.SegmentRequest{
+				Name:   "content.txt",
+				Reader: strings.NewReader(text),
+
+				SegmentLength:  wingman.Ptr(c.Size),
+				SegmentOverlap: c.Overlap,
+			})
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		var chunks []chunk.Chunk
+
+		ranges := lineRanges(text, segmentTexts(segments))
+
+		for i, s := range segments {
+			chunks = append(chunks, chunk.Chunk{
+				Text: s.Text,
+
+				LineStart: ranges[i][0],
+				LineEnd:   ranges[i][1],
+			})
+		}
+
+		return chunks, nil
+
+	case ChunkMarkdown:
+		return chunk.Markdown(text, c.Size, *c.Overlap), nil
+
+	case ChunkParagraph:
+		return chunk.Paragraphs(text, c.Size, *c.Overlap), nil
+
+	case ChunkCode:
+		return chunk.Code(name, text, c.Size, *c.Overlap), nil
+	}
+
+	return nil, fmt.Errorf("unknown chunking strategy %q", c.Strategy)
+}
+
+// chunkDocuments turns chunks into documents of source, with metadata
+// describing their position and how they were created.
+func chunkDocuments(source string, chunks []chunk.Chunk, c Chunking, metadata map[string]string) []index.Document {
+	var documents []index.Document
+
+	for i, chunk := range chunks {
+		document := index.Document{
+			Source:  source,
+			Content: chunk.Text,
+
+			Metadata: map[string]string{
+				"index":    fmt.Sprintf("%d", i),
+				"chunking": c.String(),
+			},
+		}
+
+		for k, v := range metadata {
+			document.Metadata[k] = v
+		}
+
+		if chunk.LineStart > 0 {
+			document.Metadata["line_start"] = fmt.Sprintf("%d", chunk.LineStart)
+			document.Metadata["line_end"] = fmt.Sprintf("%d", chunk.LineEnd)
+		}
+
+		if len(chunk.Symbols) > 0 {
+			document.Metadata["symbols"] = strings.Join(chunk.Symbols, ",")
+		}
+
+		if chunk.Section != "" {
+			document.Metadata["section"] = chunk.Section
+		}
+
+		documents = append(documents, document)
+	}
+
+	return documents
+}
@@ -0,0 +1,254 @@
+package rag
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+const (
+	DefaultConcurrency = 4
+	DefaultMaxFileSize = 50 << 20
+
+	DefaultHistoryMaxCommits  = 500
+	DefaultHistoryMaxDiffSize = 20000
+)
+
+var DefaultExtensions = []string{
+	".csv",
+	".md",
+	".rst",
+	".tsv",
+	".txt",
+
+	".htm",
+	".html",
+
+	".pdf",
+
+	// ".jpg", ".jpeg",
+	// ".png",
+	// ".bmp",
+	// ".tiff",
+	// ".heif",
+
+	".docx",
+	".pptx",
+	".xlsx",
+
+	".go",
+	".py",
+	".js", ".jsx", ".ts", ".tsx",
+	".java", ".kt", ".cs",
+	".c", ".h", ".cpp", ".hpp",
+	".rs",
+	".rb",
+	".php",
+	".swift",
+}
+
+type Config struct {
+	// Include limits indexing to files matching one of these globs
+	Include []string `json:"include" yaml:"include"`
+
+	// Exclude skips files and directories matching one of these globs
+	Exclude []string `json:"exclude" yaml:"exclude"`
+
+	Extensions []string `json:"extensions" yaml:"extensions"`
+
+	// MaxFileSize in bytes, negative values disable the limit
+	MaxFileSize int64 `json:"max_file_size" yaml:"max_file_size"`
+
+	// Concurrency is the number of files processed in parallel
+	Concurrency int `json:"concurrency" yaml:"concurrency"`
+
+	// Resources are uris of MCP resource templates to index in addition to
+	// the resources listed by the servers
+	Resources []string `json:"resources" yaml:"resources"`
+
+	// History indexes the commits of the git repository of the root
+	History HistoryConfig `json:"history" yaml:"history"`
+
+	Chunking ChunkingConfig `json:"chunking" yaml:"chunking"`
+
+	Retrieval RetrievalConfig `json:"retrieval" yaml:"retrieval"`
+}
+
+type ChunkingConfig struct {
+	Chunking `yaml:",inline"`
+
+	// Extensions overrides the chunking of files by their extension
+	Extensions map[string]Chunking `json:"extensions" yaml:"extensions"`
+}
+
+type HistoryConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Range of commits to index, e.g. "v1.0..HEAD"; defaults to HEAD
+	Range string `json:"range" yaml:"range"`
+
+	// Since limits indexing to commits more recent than a date, e.g.
+	// "2024-01-01" or "6 months ago"
+	Since string `json:"since" yaml:"since"`
+
+	// MaxCommits limits indexing to the most recent commits
+	MaxCommits int `json:"max_commits" yaml:"max_commits"`
+
+	// Paths limits indexing to commits touching these paths
+	Paths []string `json:"paths" yaml:"paths"`
+
+	// MaxDiffSize in bytes of the diff indexed per commit, negative values
+	// index commit messages only
+	MaxDiffSize int `json:"max_diff_size" yaml:"max_diff_size"`
+}
+
+type RetrievalConfig struct {
+	// Limit is the number of chunks returned per query
+	Limit int `json:"limit" yaml:"limit"`
+
+	// Candidates is the number of chunks fetched before reranking
+	Candidates int `json:"candidates" yaml:"candidates"`
+
+	// Rerank selects the reranker: "server", "llm" or empty to disable
+	Rerank      string `json:"rerank" yaml:"rerank"`
+	RerankModel string `json:"rerank_model" yaml:"rerank_model"`
+
+	// Diversity between 0 and 1 spreads results across passages and sources
+	// by maximal marginal relevance; off unless set here or with --diversity
+	Diversity *float64 `json:"diversity" yaml:"diversity"`
+
+	// Rewrite condenses follow-up questions into standalone queries
+	Rewrite      *bool  `json:"rewrite" yaml:"rewrite"`
+	RewriteModel string `json:"rewrite_model" yaml:"rewrite_model"`
+
+	// Queries is the number of additional sub-queries per question
+	Queries int `json:"queries" yaml:"queries"`
+
+	// HyDE also searches for a hypothetical answer to the question
+	HyDE bool `json:"hyde" yaml:"hyde"`
+}
+
+func LoadConfig(root string) (*Config, error) {
+	for _, name := range []string{".rag.json", ".rag.yaml", "rag.json", "rag.yaml"} {
+		path := filepath.Join(root, name)
+
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+
+		return ParseConfig(path)
+	}
+
+	return defaultConfig(new(Config)), nil
+}
+
+func ParseConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		config = Config{}
+
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, errors.New("failed to parse config file")
+		}
+	}
+
+	if err := defaultConfig(&config).validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return &config, nil
+}
+
+// validate checks the settings that cannot be defaulted.
+func (c *Config) validate() error {
+	if !validStrategy(c.Chunking.Strategy) {
+		return fmt.Errorf("unknown chunking strategy %q", c.Chunking.Strategy)
+	}
+
+	for ext, chunking := range c.Chunking.Extensions {
+		if !validStrategy(chunking.Strategy) {
+			return fmt.Errorf("unknown chunking strategy %q for %s files", chunking.Strategy, ext)
+		}
+	}
+
+	return nil
+}
+
+func validStrategy(strategy string) bool {
+	switch strategy {
+	case "", ChunkFixed, ChunkMarkdown, ChunkParagraph, ChunkCode:
+		return true
+	}
+
+	return false
+}
+
+func defaultConfig(config *Config) *Config {
+	if len(config.Extensions) == 0 {
+		config.Extensions = DefaultExtensions
+	}
+
+	for i, ext := range config.Extensions {
+		ext = strings.ToLower(ext)
+
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+
+		config.Extensions[i] = ext
+	}
+
+	config.Chunking.Strategy = strings.ToLower(strings.TrimSpace(config.Chunking.Strategy))
+
+	extensions := make(map[string]Chunking, len(config.Chunking.Extensions))
+
+	for ext, c := range config.Chunking.Extensions {
+		ext = strings.ToLower(ext)
+
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+
+		c.Strategy = strings.ToLower(strings.TrimSpace(c.Strategy))
+
+		extensions[ext] = c
+	}
+
+	config.Chunking.Extensions = extensions
+
+	if config.Retrieval.Rewrite == nil {
+		config.Retrieval.Rewrite = wingman.Ptr(true)
+	}
+
+	if config.Concurrency <= 0 {
+		config.Concurrency = DefaultConcurrency
+	}
+
+	if config.MaxFileSize == 0 {
+		config.MaxFileSize = DefaultMaxFileSize
+	}
+
+	if config.History.MaxCommits <= 0 {
+		config.History.MaxCommits = DefaultHistoryMaxCommits
+	}
+
+	if config.History.MaxDiffSize == 0 {
+		config.History.MaxDiffSize = DefaultHistoryMaxDiffSize
+	}
+
+	return config
+}
@@ -8,73 +8,161 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"net/http"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 
-	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/pkg/chunk"
+	"github.com/adrianliechti/wingman-cli/pkg/extract"
+	"github.com/adrianliechti/wingman-cli/pkg/ignore"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
 	wingman "github.com/adrianliechti/wingman/pkg/client"
-	"github.com/adrianliechti/wingman/pkg/index"
 )
 
-func IndexDir(ctx context.Context, client *wingman.Client, i index.Provider, root string) error {
-	supported := []string{
-		".csv",
-		".md",
-		".rst",
-		".tsv",
-		".txt",
+type Summary struct {
+	Added     int
+	Updated   int
+	Removed   int
+	Unchanged int
+	Failed    int
 
-		".pdf",
+	Chunks int
+}
+
+func (s *Summary) String() string {
+	return fmt.Sprintf("%d added, %d updated, %d removed, %d unchanged, %d failed, %d chunks indexed", s.Added, s.Updated, s.Removed, s.Unchanged, s.Failed, s.Chunks)
+}
+
+type file struct {
+	path string
+	rel  string
+}
+
+type fileState int
 
-		// ".jpg", ".jpeg",
-		// ".png",
-		// ".bmp",
-		// ".tiff",
-		// ".heif",
+const (
+	fileUnchanged fileState = iota
+	fileAdded
+	fileUpdated
+)
 
-		".docx",
-		".pptx",
-		".xlsx",
+func IndexDir(ctx context.Context, client *wingman.Client, i *index.Index, root string, config *Config) (*Summary, error) {
+	if config == nil {
+		config = defaultConfig(new(Config))
 	}
 
-	var cursor string
+	sources, err := i.WriteSources(ctx)
 
-	mapping := make(map[string]string)
-	candidates := make(map[string]string)
+	if err != nil {
+		return nil, err
+	}
 
-	for {
-		page, err := i.List(ctx, &index.ListOptions{
-			Cursor: cursor,
-		})
+	candidates := make(map[string]index.Source)
 
-		if err != nil {
-			return err
+	for _, s := range sources {
+		// sources of other kinds (e.g. resource uris) are not managed here
+		if !strings.HasPrefix(s.Name, "/") {
+			continue
 		}
 
-		cursor = page.Cursor
+		candidates[s.Name] = s
+	}
+
+	files, result := collectFiles(root, config)
 
-		if len(page.Items) == 0 {
-			break
-		}
+	summary := &Summary{}
+	progress := newProgress(len(files))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	jobs := make(chan file)
 
-		for _, i := range page.Items {
-			path := i.Metadata["path"]
-			revision := i.Metadata["revision"]
+	for range config.Concurrency {
+		wg.Add(1)
 
-			if path == "" || revision == "" {
-				continue
+		go func() {
+			defer wg.Done()
+
+			for f := range jobs {
+				state, chunks, err := indexFile(ctx, client, i, config, f, candidates["/"+f.rel], progress)
+
+				mu.Lock()
+
+				switch {
+				case err != nil:
+					summary.Failed++
+					result = errors.Join(result, fmt.Errorf("/%s: %w", f.rel, err))
+				case state == fileAdded:
+					summary.Added++
+				case state == fileUpdated:
+					summary.Updated++
+				default:
+					summary.Unchanged++
+				}
+
+				summary.Chunks += chunks
+
+				mu.Unlock()
+
+				progress.Increment()
 			}
+		}()
+	}
+
+feed:
+	for _, f := range files {
+		select {
+		case jobs <- f:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	progress.Finish()
+
+	if err := ctx.Err(); err != nil {
+		return summary, err
+	}
+
+	seen := make(map[string]bool, len(files))
 
-			mapping[path] = i.ID
-			candidates[path] = revision
+	for _, f := range files {
+		seen["/"+f.rel] = true
+	}
+
+	var deletions []string
+
+	for path := range candidates {
+		if seen[path] {
+			continue
+		}
+
+		deletions = append(deletions, path)
+	}
+
+	if len(deletions) > 0 {
+		if err := i.DeleteSources(ctx, deletions...); err != nil {
+			result = errors.Join(result, err)
+		} else {
+			summary.Removed = len(deletions)
 		}
 	}
 
+	return summary, result
+}
+
+func collectFiles(root string, config *Config) ([]file, error) {
+	var files []file
 	var result error
 
-	revisions := make(map[string]string)
+	ignores := ignore.New()
 
 	filepath.WalkDir(root, func(path string, e fs.DirEntry, err error) error {
 		if err != nil {
@@ -82,108 +170,183 @@ func IndexDir(ctx context.Context, client *wingman.Client, i index.Provider, roo
 			return nil
 		}
 
-		if e.IsDir() || !slices.Contains(supported, filepath.Ext(path)) {
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+
+		if rel != "." && skipPath(config, ignores, rel, e.IsDir()) {
+			if e.IsDir() {
+				return filepath.SkipDir
+			}
+
 			return nil
 		}
 
-		name := filepath.Base(path)
-
-		rel, _ := filepath.Rel(root, path)
-		rel = filepath.ToSlash(rel)
+		if e.IsDir() {
+			for _, f := range []string{".gitignore", ".wingmanignore"} {
+				if err := ignores.AddFile(filepath.Join(path, f), strings.TrimPrefix(rel, ".")); err != nil {
+					result = errors.Join(result, err)
+				}
+			}
 
-		if strings.HasPrefix(rel, ".") || strings.Contains(path, "/.") {
 			return nil
 		}
 
-		data, err := os.ReadFile(path)
+		if !slices.Contains(config.Extensions, strings.ToLower(filepath.Ext(path))) {
+			return nil
+		}
 
-		if err != nil {
-			result = errors.Join(result, err)
+		if len(config.Include) > 0 && !matchAny(config.Include, rel) {
 			return nil
 		}
 
-		md5_hash := md5.Sum(data)
-		md5_text := hex.EncodeToString(md5_hash[:])
+		if config.MaxFileSize > 0 {
+			info, err := e.Info()
 
-		revisions["/"+rel] = md5_text
+			if err != nil {
+				result = errors.Join(result, err)
+				return nil
+			}
 
-		if revision, ok := candidates["/"+rel]; ok {
-			if strings.EqualFold(revision, md5_text) {
+			if info.Size() > config.MaxFileSize {
 				return nil
 			}
 		}
 
-		cli.Infof("Indexing /%s...", rel)
-
-		extraction, err := client.Extractions.New(ctx, wingman.ExtractionRequest{
-			Name:   name,
-			Reader: bytes.NewReader(data),
+		files = append(files, file{
+			path: path,
+			rel:  rel,
 		})
 
-		if err != nil {
-			result = errors.Join(result, err)
-			return nil
-		}
+		return nil
+	})
 
-		segments, err := client.Segments.New(ctx, wingman.SegmentRequest{
-			Name:   "content.txt",
-			Reader: strings.NewReader(extraction.Text),
+	return files, result
+}
 
-			SegmentLength:  wingman.Ptr(3000),
-			SegmentOverlap: wingman.Ptr(1500),
-		})
+// indexFile indexes f unless its content and chunking match the indexed
+// source.
+func indexFile(ctx context.Context, client *wingman.Client, i *index.Index, config *Config, f file, indexed index.Source, progress *progress) (fileState, int, error) {
+	data, err := os.ReadFile(f.path)
+
+	if err != nil {
+		return fileUnchanged, 0, err
+	}
+
+	md5_hash := md5.Sum(data)
+	md5_text := hex.EncodeToString(md5_hash[:])
+
+	chunking := config.ChunkingFor(f.path)
+
+	if strings.EqualFold(indexed.Revision, md5_text) && chunking.matches(indexed.Chunking) {
+		return fileUnchanged, 0, nil
+	}
+
+	progress.Logf("Indexing /%s...", f.rel)
+
+	// source code and other text is chunked as is, everything else is
+	// converted to text first, locally if possible
+	text := string(data)
+
+	var pages []int
+
+	extracted := needsExtraction(f.path, http.DetectContentType(data))
+
+	if extracted {
+		text, err = extractText(ctx, client, f.path, data)
 
 		if err != nil {
-			result = errors.Join(result, err)
-			return nil
+			return fileUnchanged, 0, err
 		}
 
-		var documents []index.Document
+		text, pages = splitPages(text)
+	}
 
-		for i, segment := range segments {
-			document := index.Document{
-				Content: segment.Text,
+	chunks, err := chunkText(ctx, client, f.path, text, chunking)
 
-				Metadata: map[string]string{
-					"path": "/" + rel,
+	if err != nil {
+		return fileUnchanged, 0, err
+	}
 
-					"index":    fmt.Sprintf("%d", i),
-					"revision": md5_text,
-				},
-			}
+	metadata := map[string]string{
+		"path":     "/" + f.rel,
+		"revision": md5_text,
+	}
 
-			documents = append(documents, document)
-		}
+	if language := chunk.Language(f.path); language != "" {
+		metadata["language"] = language
+	}
 
-		if err := i.Index(ctx, documents...); err != nil {
-			result = errors.Join(result, err)
-			return nil
+	documents := chunkDocuments("/"+f.rel, chunks, chunking, metadata)
+
+	addPages(documents, pages, f.path)
+
+	if extracted && !extract.Plain(f.path) {
+		dropLines(documents)
+	}
+
+	if err := i.Replace(ctx, "/"+f.rel, documents...); err != nil {
+		return fileUnchanged, 0, err
+	}
+
+	if indexed.Name == "" {
+		return fileAdded, len(documents), nil
+	}
+
+	return fileUpdated, len(documents), nil
+}
+
+// extractText converts a file to text, using the server only for formats
+// that cannot be extracted locally.
+func extractText(ctx context.Context, client *wingman.Client, path string, data []byte) (string, error) {
+	if extract.Supported(path) {
+		if text, err := extract.Text(path, data); err == nil {
+			return text, nil
 		}
+	}
 
-		return nil
+	extraction, err := retry(ctx, func() (*wingman.Extraction, error) {
+		return client.Extractions.New(ctx, wingman.ExtractionRequest{
+			Name:   filepath.Base(path),
+			Reader: bytes.NewReader(data),
+		})
 	})
 
-	var deletions []string
+	if err != nil {
+		return "", err
+	}
 
-	for path := range candidates {
-		if _, ok := revisions[path]; ok {
-			continue
-		}
+	return extraction.Text, nil
+}
+
+func skipPath(config *Config, ignores *ignore.Matcher, rel string, isDir bool) bool {
+	if strings.HasPrefix(filepath.Base(rel), ".") {
+		return true
+	}
+
+	if ignores.Match(rel, isDir) {
+		return true
+	}
 
-		id, found := mapping[path]
+	return matchAny(config.Exclude, rel)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		p = strings.Trim(p, "/")
+
+		// patterns without a slash match the name at any depth
+		if !strings.Contains(p, "/") {
+			if ignore.Glob(p, filepath.Base(rel)) {
+				return true
+			}
 
-		if !found {
 			continue
 		}
 
-		deletions = append(deletions, id)
-	}
-
-	if len(deletions) > 0 {
-		if err := i.Delete(ctx, deletions...); err != nil {
-			result = errors.Join(result, err)
+		if ignore.Glob(p, rel) {
+			return true
 		}
 	}
 
-	return result
+	return false
 }
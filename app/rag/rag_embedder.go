@@ -2,22 +2,68 @@ package rag
 
 import (
 	"context"
+	"path/filepath"
+	"slices"
+
+	"github.com/adrianliechti/wingman-cli/app"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
 
 	wingman "github.com/adrianliechti/wingman/pkg/client"
 )
 
+const DefaultBatchSize = 32
+
 type embeder struct {
 	client *wingman.Client
+
+	model     string
+	batchSize int
+}
+
+func (e *embeder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	size := e.batchSize
+
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+
+	result := make([][]float32, 0, len(texts))
+
+	for batch := range slices.Chunk(texts, size) {
+		embeddings, err := retry(ctx, func() (*wingman.Embedding, error) {
+			return e.client.Embeddings.New(ctx, wingman.EmbeddingsRequest{
+				Model: e.model,
+				Texts: batch,
+			})
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, embeddings.Embeddings...)
+	}
+
+	return result, nil
 }
 
-func (e *embeder) Embed(ctx context.Context, text string) ([]float32, error) {
-	embeddings, err := e.client.Embeddings.New(ctx, wingman.EmbeddingsRequest{
-		Texts: []string{text},
-	})
+func newEmbedder(client *wingman.Client, model string, batchSize int, cache bool) (index.Embedder, error) {
+	e := &embeder{
+		client: client,
+
+		model:     model,
+		batchSize: batchSize,
+	}
+
+	if !cache {
+		return e, nil
+	}
+
+	dir, err := app.CacheDir()
 
 	if err != nil {
 		return nil, err
 	}
 
-	return embeddings.Embeddings[0], nil
+	return index.NewCachedEmbedder(filepath.Join(dir, "embeddings.db"), model, e)
 }
@@ -0,0 +1,444 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/app"
+	"github.com/adrianliechti/wingman-cli/pkg/agent"
+	"github.com/adrianliechti/wingman-cli/pkg/tool/retriever"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+// EvalSet is a golden set of questions along with the sources that should be
+// retrieved to answer them.
+type EvalSet struct {
+	Questions []EvalQuestion `json:"questions" yaml:"questions"`
+}
+
+type EvalQuestion struct {
+	Question string `json:"question" yaml:"question"`
+
+	// Sources are the paths or uris expected among the retrieved chunks,
+	// paths may contain glob patterns
+	Sources []string `json:"sources" yaml:"sources"`
+
+	// Answer is an optional reference answer for the judge
+	Answer string `json:"answer" yaml:"answer"`
+}
+
+type EvalOptions struct {
+	// TopK overrides the number of chunks retrieved per question
+	TopK int
+
+	// Judge generates an answer for each question and grades it
+	Judge      bool
+	JudgeModel string
+
+	JSON bool
+}
+
+type EvalResult struct {
+	Question string `json:"question"`
+
+	Expected  []string `json:"expected,omitempty"`
+	Retrieved []string `json:"retrieved"`
+
+	Recall         float64 `json:"recall"`
+	ReciprocalRank float64 `json:"reciprocal_rank"`
+
+	Latency float64 `json:"latency_ms"`
+
+	Answer        string  `json:"answer,omitempty"`
+	AnswerLatency float64 `json:"answer_latency_ms,omitempty"`
+
+	Score  int    `json:"score,omitempty"`
+	Reason string `json:"reason,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+type EvalReport struct {
+	K int `json:"k"`
+
+	Questions int `json:"questions"`
+
+	Recall float64 `json:"recall"`
+	MRR    float64 `json:"mrr"`
+
+	LatencyMean float64 `json:"latency_mean_ms"`
+	LatencyP50  float64 `json:"latency_p50_ms"`
+	LatencyP95  float64 `json:"latency_p95_ms"`
+
+	Score float64 `json:"score,omitempty"`
+
+	Results []EvalResult `json:"results"`
+}
+
+// Eval runs the questions of an eval set against the index of the directory
+// in the options and reports recall@k, MRR and retrieval latency, and, if
+// judging, the grades of the generated answers.
+func Eval(ctx context.Context, client *wingman.Client, model, path string, options *Options, evalOptions *EvalOptions) error {
+	if evalOptions == nil {
+		evalOptions = new(EvalOptions)
+	}
+
+	if path == "" {
+		return errors.New("eval file is required")
+	}
+
+	set, err := ParseEvalSet(path)
+
+	if err != nil {
+		return err
+	}
+
+	root, err := RootDir(options)
+
+	if err != nil {
+		return err
+	}
+
+	i, err := OpenExistingIndex(client, root, options)
+
+	if err != nil {
+		return err
+	}
+
+	config, err := LoadConfig(root)
+
+	if err != nil {
+		return err
+	}
+
+	if evalOptions.TopK > 0 {
+		config.Retrieval.Limit = evalOptions.TopK
+	}
+
+	k := config.Retrieval.Limit
+
+	if k <= 0 {
+		k = retriever.DefaultLimit
+	}
+
+	r, err := newRetriever(ctx, client, i, root, config, options)
+
+	if err != nil {
+		return err
+	}
+
+	var instructions string
+
+	if evalOptions.Judge {
+		if instructions, err = app.ParseInstructions(); err != nil {
+			return err
+		}
+
+		if instructions == "" {
+			instructions = DefaultPrompt
+		}
+	}
+
+	judgeModel := evalOptions.JudgeModel
+
+	if judgeModel == "" {
+		judgeModel = model
+	}
+
+	report := &EvalReport{
+		K: k,
+
+		Questions: len(set.Questions),
+	}
+
+	var latencies []float64
+	var scored, graded int
+
+	for n, q := range set.Questions {
+		if !evalOptions.JSON {
+			cli.Infof("Evaluating %d/%d: %s", n+1, len(set.Questions), q.Question)
+		}
+
+		result := evalQuestion(ctx, r, q)
+
+		latencies = append(latencies, result.Latency)
+
+		if len(q.Sources) > 0 {
+			scored++
+
+			report.Recall += result.Recall
+			report.MRR += result.ReciprocalRank
+		}
+
+		if evalOptions.Judge && result.Error == "" {
+			if err := judgeQuestion(ctx, client, r, model, judgeModel, instructions, q, &result); err != nil {
+				result.Error = err.Error()
+			} else {
+				graded++
+				report.Score += float64(result.Score)
+			}
+		}
+
+		if result.Error != "" && !evalOptions.JSON {
+			cli.Warn(fmt.Errorf("%s: %s", q.Question, result.Error))
+		}
+
+		report.Results = append(report.Results, result)
+	}
+
+	if scored > 0 {
+		report.Recall /= float64(scored)
+		report.MRR /= float64(scored)
+	}
+
+	if graded > 0 {
+		report.Score /= float64(graded)
+	}
+
+	if len(latencies) > 0 {
+		for _, l := range latencies {
+			report.LatencyMean += l
+		}
+
+		report.LatencyMean /= float64(len(latencies))
+
+		slices.Sort(latencies)
+
+		report.LatencyP50 = percentile(latencies, 0.5)
+		report.LatencyP95 = percentile(latencies, 0.95)
+	}
+
+	if evalOptions.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(report)
+	}
+
+	printReport(report, evalOptions.Judge)
+
+	return nil
+}
+
+// ParseEvalSet reads an eval set from a json or yaml file.
+func ParseEvalSet(path string) (*EvalSet, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var set EvalSet
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		if err := yaml.Unmarshal(data, &set); err != nil {
+			return nil, errors.New("failed to parse eval file")
+		}
+	}
+
+	if len(set.Questions) == 0 {
+		return nil, errors.New("eval file contains no questions")
+	}
+
+	for n, q := range set.Questions {
+		if strings.TrimSpace(q.Question) == "" {
+			return nil, fmt.Errorf("question %d is empty", n+1)
+		}
+
+		for k, s := range q.Sources {
+			set.Questions[n].Sources[k] = SourceName(s)
+		}
+	}
+
+	return &set, nil
+}
+
+func evalQuestion(ctx context.Context, r *retriever.Retriever, q EvalQuestion) EvalResult {
+	result := EvalResult{
+		Question: q.Question,
+		Expected: q.Sources,
+	}
+
+	start := time.Now()
+
+	results, err := r.Retrieve(ctx, q.Question)
+
+	result.Latency = milliseconds(time.Since(start))
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	for _, d := range results {
+		result.Retrieved = append(result.Retrieved, d.Source)
+	}
+
+	if len(q.Sources) == 0 {
+		return result
+	}
+
+	var found int
+
+	for _, s := range q.Sources {
+		if slices.ContainsFunc(result.Retrieved, func(source string) bool { return matchSource(s, source) }) {
+			found++
+		}
+	}
+
+	result.Recall = float64(found) / float64(len(q.Sources))
+
+	for rank, source := range result.Retrieved {
+		if slices.ContainsFunc(q.Sources, func(s string) bool { return matchSource(s, source) }) {
+			result.ReciprocalRank = 1 / float64(rank+1)
+			break
+		}
+	}
+
+	return result
+}
+
+// judgeQuestion answers the question like rag ask does and asks the judge
+// model to grade the answer from 1 (wrong) to 5 (correct and complete).
+func judgeQuestion(ctx context.Context, client *wingman.Client, r *retriever.Retriever, model, judgeModel, instructions string, q EvalQuestion, result *EvalResult) error {
+	tools, err := r.Tools(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+
+	answer, err := agent.Ask(ctx, client, model, instructions, q.Question, tools)
+
+	if err != nil {
+		return err
+	}
+
+	result.Answer = answer
+	result.AnswerLatency = milliseconds(time.Since(started))
+
+	var prompt strings.Builder
+
+	prompt.WriteString("Question: " + q.Question + "\n\n")
+
+	if q.Answer != "" {
+		prompt.WriteString("Reference answer: " + q.Answer + "\n\n")
+	}
+
+	prompt.WriteString("Answer to grade: " + answer)
+
+	completion, err := client.Completions.New(ctx, wingman.CompletionRequest{
+		Model: judgeModel,
+
+		Messages: []wingman.Message{
+			wingman.SystemMessage("You grade answers of a question answering system. Rate the answer from 1 (wrong or unsupported) to 5 (correct and complete), comparing it with the reference answer if one is given. Reply only with a JSON object with the fields \"score\" (a number) and \"reason\" (one sentence)."),
+			wingman.UserMessage(prompt.String()),
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	content := completion.Message.Text()
+
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+
+	if start < 0 || end < start {
+		return fmt.Errorf("invalid judge response: %q", content)
+	}
+
+	var grade struct {
+		Score  float64 `json:"score"`
+		Reason string  `json:"reason"`
+	}
+
+	if err := json.Unmarshal([]byte(content[start:end+1]), &grade); err != nil {
+		return err
+	}
+
+	result.Score = min(max(int(grade.Score+0.5), 1), 5)
+	result.Reason = grade.Reason
+
+	return nil
+}
+
+func printReport(report *EvalReport, judge bool) {
+	header := []string{"Question", fmt.Sprintf("Recall@%d", report.K), "RR", "Latency"}
+
+	if judge {
+		header = append(header, "Score")
+	}
+
+	var rows [][]string
+
+	for _, r := range report.Results {
+		question := r.Question
+
+		if len(question) > 60 {
+			question = question[:57] + "..."
+		}
+
+		recall, rr := "-", "-"
+
+		if len(r.Expected) > 0 {
+			recall = fmt.Sprintf("%.2f", r.Recall)
+			rr = fmt.Sprintf("%.2f", r.ReciprocalRank)
+		}
+
+		row := []string{question, recall, rr, fmt.Sprintf("%.0fms", r.Latency)}
+
+		if judge {
+			row = append(row, fmt.Sprintf("%d", r.Score))
+		}
+
+		rows = append(rows, row)
+	}
+
+	cli.Info()
+	cli.Table(header, rows)
+	cli.Info()
+
+	cli.Infof("Questions: %d", report.Questions)
+	cli.Infof("Recall@%d:  %.3f", report.K, report.Recall)
+	cli.Infof("MRR:       %.3f", report.MRR)
+	cli.Infof("Latency:   %.0fms mean, %.0fms p50, %.0fms p95", report.LatencyMean, report.LatencyP50, report.LatencyP95)
+
+	if judge {
+		cli.Infof("Score:     %.2f / 5", report.Score)
+	}
+}
+
+func matchSource(pattern, source string) bool {
+	if pattern == source {
+		return true
+	}
+
+	ok, _ := path.Match(pattern, source)
+	return ok
+}
+
+// percentile returns the value below which the fraction p of the sorted
+// values fall, using the nearest rank.
+func percentile(sorted []float64, p float64) float64 {
+	n := int(math.Ceil(float64(len(sorted))*p)) - 1
+	return sorted[min(max(n, 0), len(sorted)-1)]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,213 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adrianliechti/wingman-cli/pkg/git"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+const kindCommit = "commit"
+
+// IndexHistory brings the index up to date with the commits of the git
+// repository containing root, within the range configured in the history
+// config. Each commit is indexed once with its message, changed files and
+// diff; commits that drop out of the range are removed.
+func IndexHistory(ctx context.Context, client *wingman.Client, i *index.Index, root string, config *Config) (*Summary, error) {
+	if config == nil {
+		config = defaultConfig(new(Config))
+	}
+
+	commits, err := git.Log(ctx, root, &git.LogOptions{
+		Range: config.History.Range,
+		Since: config.History.Since,
+
+		MaxCommits: config.History.MaxCommits,
+
+		Paths: config.History.Paths,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sources, err := i.WriteSources(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := make(map[string]index.Source)
+
+	for _, s := range sources {
+		if s.Kind == kindCommit {
+			indexed[s.Name] = s
+		}
+	}
+
+	var result error
+
+	summary := &Summary{}
+	progress := newProgress(len(commits))
+
+	seen := make(map[string]bool, len(commits))
+
+	for _, c := range commits {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		source := commitSource(c.Hash)
+		seen[source] = true
+
+		state, chunks, err := indexCommit(ctx, client, i, root, config, c, indexed[source], progress)
+
+		switch {
+		case err != nil:
+			summary.Failed++
+			result = errors.Join(result, fmt.Errorf("%s: %w", c.Hash, err))
+		case state == fileAdded:
+			summary.Added++
+		case state == fileUpdated:
+			summary.Updated++
+		default:
+			summary.Unchanged++
+		}
+
+		summary.Chunks += chunks
+
+		progress.Increment()
+	}
+
+	progress.Finish()
+
+	var removed []string
+
+	for name := range indexed {
+		if !seen[name] {
+			removed = append(removed, name)
+		}
+	}
+
+	if len(removed) > 0 {
+		if err := i.DeleteSources(ctx, removed...); err != nil {
+			return summary, errors.Join(result, err)
+		}
+
+		summary.Removed = len(removed)
+	}
+
+	return summary, result
+}
+
+// indexCommit indexes a commit unless it was indexed with the same chunking
+// before. Commits never change, so their hash serves as revision.
+func indexCommit(ctx context.Context, client *wingman.Client, i *index.Index, root string, config *Config, c git.Commit, indexed index.Source, progress *progress) (fileState, int, error) {
+	name := "commit.diff"
+	chunking := config.ChunkingFor(name)
+
+	if indexed.Revision == c.Hash && chunking.matches(indexed.Chunking) {
+		return fileUnchanged, 0, nil
+	}
+
+	progress.Logf("Indexing commit %s %s...", c.Hash[:min(len(c.Hash), 12)], c.Subject)
+
+	files, err := git.Files(ctx, root, c.Hash)
+
+	if err != nil {
+		return fileUnchanged, 0, err
+	}
+
+	var diff string
+	var truncated bool
+
+	if config.History.MaxDiffSize > 0 {
+		if diff, truncated, err = git.Diff(ctx, root, c.Hash, config.History.MaxDiffSize); err != nil {
+			return fileUnchanged, 0, err
+		}
+	}
+
+	text := commitText(c, files, diff, truncated)
+
+	chunks, err := chunkText(ctx, client, name, text, chunking)
+
+	if err != nil {
+		return fileUnchanged, 0, err
+	}
+
+	// later chunks only hold parts of the diff, the header tells which
+	// change they belong to
+	header := fmt.Sprintf("commit %s\n%s\n\n", c.Hash, c.Subject)
+
+	for n := range chunks {
+		if n > 0 && !strings.HasPrefix(chunks[n].Text, "commit "+c.Hash) {
+			chunks[n].Text = header + chunks[n].Text
+		}
+
+		// line numbers of the rendered commit do not refer to any file
+		chunks[n].LineStart = 0
+		chunks[n].LineEnd = 0
+	}
+
+	documents := chunkDocuments(commitSource(c.Hash), chunks, chunking, map[string]string{
+		"kind":     kindCommit,
+		"revision": c.Hash,
+
+		"commit": c.Hash,
+		"author": fmt.Sprintf("%s <%s>", c.Author, c.Email),
+		"date":   c.Date.Format(time.RFC3339),
+	})
+
+	if err := i.Replace(ctx, commitSource(c.Hash), documents...); err != nil {
+		return fileUnchanged, 0, err
+	}
+
+	if indexed.Name == "" {
+		return fileAdded, len(documents), nil
+	}
+
+	return fileUpdated, len(documents), nil
+}
+
+func commitSource(hash string) string {
+	return "commit/" + hash
+}
+
+// commitText renders a commit similar to git show.
+func commitText(c git.Commit, files []string, diff string, truncated bool) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "commit %s\n", c.Hash)
+	fmt.Fprintf(&b, "Author: %s <%s>\n", c.Author, c.Email)
+	fmt.Fprintf(&b, "Date:   %s\n\n", c.Date.Format(time.RFC1123Z))
+
+	b.WriteString(c.Subject + "\n")
+
+	if c.Body != "" {
+		b.WriteString("\n" + c.Body + "\n")
+	}
+
+	if len(files) > 0 {
+		b.WriteString("\nChanged files:\n")
+
+		for _, f := range files {
+			b.WriteString("- " + f + "\n")
+		}
+	}
+
+	if diff != "" {
+		b.WriteString("\n" + diff + "\n")
+
+		if truncated {
+			b.WriteString("[diff truncated]\n")
+		}
+	}
+
+	return b.String()
+}
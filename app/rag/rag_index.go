@@ -0,0 +1,147 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrianliechti/wingman-cli/app"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+// RootDir returns the absolute directory set in the options, or else the
+// working directory.
+func RootDir(options *Options) (string, error) {
+	if options != nil && options.Dir != "" {
+		return filepath.Abs(options.Dir)
+	}
+
+	return app.Dir()
+}
+
+// IndexPath returns the database holding the index of root: the path set in
+// the options, a wingman.db in root as created by earlier versions, or else a
+// database in the user cache dir keyed by the path of root.
+func IndexPath(root string, options *Options) (string, error) {
+	if options != nil && options.Index != "" {
+		return filepath.Abs(options.Index)
+	}
+
+	root, err := filepath.Abs(root)
+
+	if err != nil {
+		return "", err
+	}
+
+	legacy := filepath.Join(root, "wingman.db")
+
+	if _, err := os.Stat(legacy); err == nil {
+		return legacy, nil
+	}
+
+	dir, err := app.CacheDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	dir = filepath.Join(dir, "index")
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256([]byte(root))
+	name := fmt.Sprintf("%s-%s.db", filepath.Base(root), hex.EncodeToString(hash[:])[:16])
+
+	return filepath.Join(dir, name), nil
+}
+
+// Index brings the index of the directory in the options up to date without
+// starting a chat.
+func Index(ctx context.Context, client *wingman.Client, options *Options) error {
+	if options == nil {
+		options = new(Options)
+	}
+
+	root, err := RootDir(options)
+
+	if err != nil {
+		return err
+	}
+
+	_, _, summary, err := buildIndex(ctx, client, root, options)
+
+	if err != nil {
+		return err
+	}
+
+	if summary.Failed > 0 {
+		return fmt.Errorf("failed to index %d files", summary.Failed)
+	}
+
+	return nil
+}
+
+// OpenExistingIndex opens the index of root, failing if none was built yet.
+func OpenExistingIndex(client *wingman.Client, root string, options *Options) (*index.Index, error) {
+	path, err := IndexPath(root, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("no index found at " + path)
+		}
+
+		return nil, err
+	}
+
+	return OpenIndex(client, path, options)
+}
+
+func OpenIndex(client *wingman.Client, path string, options *Options) (*index.Index, error) {
+	if options == nil {
+		options = new(Options)
+	}
+
+	embedder, err := newEmbedder(client, options.EmbeddingModel, options.BatchSize, !options.NoCache)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return index.New(path, embedder, &index.Options{
+		Model: options.EmbeddingModel,
+
+		Collections: options.Collections,
+	})
+}
+
+// SourceName normalizes a source given by the user to the form of indexed
+// sources: uris and commits as is, paths relative to the root with a leading
+// slash.
+func SourceName(s string) string {
+	s = strings.TrimSpace(s)
+
+	if u, err := url.Parse(s); err == nil && len(u.Scheme) > 1 {
+		return s
+	}
+
+	if strings.HasPrefix(s, commitSource("")) {
+		return s
+	}
+
+	return path.Join("/", filepath.ToSlash(s))
+}
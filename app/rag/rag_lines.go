@@ -0,0 +1,55 @@
+package rag
+
+import (
+	"strings"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+// lineRanges locates each segment in text and returns its first and last
+// line (1-based). Segments are expected in order and may overlap; segments
+// that cannot be located get a zero range.
+func lineRanges(text string, segments []string) [][2]int {
+	ranges := make([][2]int, len(segments))
+
+	offset := 0
+
+	for n, segment := range segments {
+		probe := strings.TrimSpace(segment)
+
+		if len(probe) > 200 {
+			probe = probe[:200]
+		}
+
+		if probe == "" {
+			continue
+		}
+
+		pos := strings.Index(text[offset:], probe)
+
+		if pos < 0 {
+			continue
+		}
+
+		pos += offset
+
+		start := strings.Count(text[:pos], "\n") + 1
+		end := start + strings.Count(strings.TrimSpace(segment), "\n")
+
+		ranges[n] = [2]int{start, end}
+
+		offset = pos + 1
+	}
+
+	return ranges
+}
+
+func segmentTexts(segments []wingman.Segment) []string {
+	texts := make([]string, 0, len(segments))
+
+	for _, s := range segments {
+		texts = append(texts, s.Text)
+	}
+
+	return texts
+}
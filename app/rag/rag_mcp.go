@@ -6,123 +6,242 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"slices"
+	"mime"
+	"net/url"
+	"path"
 	"strings"
 
 	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/pkg/extract"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
 	"github.com/adrianliechti/wingman-cli/pkg/resource"
 	wingman "github.com/adrianliechti/wingman/pkg/client"
-	"github.com/adrianliechti/wingman/pkg/index"
 )
 
-func IndexResources(ctx context.Context, client *wingman.Client, i index.Provider, resources []resource.Resource) error {
-	if len(resources) == 0 {
-		return nil
-	}
-
-	supported := []string{
-		"text/plain",
-		"text/markdown",
-	}
+const kindResource = "resource"
 
-	var cursor string
-
-	mapping := make(map[string]string)
+// IndexResources brings the index up to date with the MCP resources and the
+// instances of resource templates listed in the config. Resources are
+// re-indexed when their content or chunking changes and removed once they
+// vanish.
+func IndexResources(ctx context.Context, client *wingman.Client, i *index.Index, config *Config, resources []resource.Resource, templates []resource.Template) (*Summary, error) {
+	var result error
 
-	for {
-		page, err := i.List(ctx, &index.ListOptions{
-			Cursor: cursor,
-		})
+	for _, uri := range config.Resources {
+		r, err := templateResource(templates, uri)
 
 		if err != nil {
-			return err
+			result = errors.Join(result, err)
+			continue
 		}
 
-		cursor = page.Cursor
+		resources = append(resources, r)
+	}
 
-		if len(page.Items) == 0 {
-			break
-		}
+	sources, err := i.WriteSources(ctx)
 
-		for _, i := range page.Items {
-			uri := i.Metadata["uri"]
+	if err != nil {
+		return nil, err
+	}
 
-			if uri == "" {
-				continue
-			}
+	indexed := make(map[string]index.Source)
 
-			mapping[uri] = i.ID
+	for _, s := range sources {
+		// resources indexed before kinds were recorded are the only sources
+		// without a kind that are not files
+		if s.Kind == kindResource || (s.Kind == "" && !strings.HasPrefix(s.Name, "/")) {
+			indexed[s.Name] = s
 		}
 	}
 
-	var result error
+	summary := &Summary{}
+
+	seen := make(map[string]bool)
 
 	for _, r := range resources {
-		if !slices.Contains(supported, r.ContentType) {
+		if seen[r.URI] {
 			continue
 		}
 
-		if _, found := mapping[r.URI]; found {
-			continue
-		}
+		seen[r.URI] = true
 
-		data, err := r.Content(ctx)
+		state, chunks, err := indexResource(ctx, client, i, config, r, indexed[r.URI])
 
 		if err != nil {
-			//result = errors.Join(result, err)
+			summary.Failed++
+			result = errors.Join(result, fmt.Errorf("%s: %w", r.URI, err))
+
 			continue
 		}
 
-		md5_hash := md5.Sum(data)
-		md5_text := hex.EncodeToString(md5_hash[:])
+		summary.Chunks += chunks
+
+		switch state {
+		case fileAdded:
+			summary.Added++
+		case fileUpdated:
+			summary.Updated++
+		default:
+			summary.Unchanged++
+		}
+	}
+
+	var removed []string
+
+	for name := range indexed {
+		if !seen[name] {
+			removed = append(removed, name)
+		}
+	}
+
+	if len(removed) > 0 {
+		if err := i.DeleteSources(ctx, removed...); err != nil {
+			return summary, errors.Join(result, err)
+		}
+
+		summary.Removed = len(removed)
+	}
+
+	return summary, result
+}
+
+func indexResource(ctx context.Context, client *wingman.Client, i *index.Index, config *Config, r resource.Resource, indexed index.Source) (fileState, int, error) {
+	data, err := r.Content(ctx)
+
+	if err != nil {
+		return fileUnchanged, 0, err
+	}
+
+	return indexContent(ctx, client, i, config, r.URI, r.ContentType, data, indexed, map[string]string{
+		"uri":  r.URI,
+		"kind": kindResource,
+	})
+}
+
+// indexContent indexes data, retrieved from uri, as source uri unless its
+// content and chunking match the indexed source.
+func indexContent(ctx context.Context, client *wingman.Client, i *index.Index, config *Config, uri, contentType string, data []byte, indexed index.Source, metadata map[string]string) (fileState, int, error) {
+	md5_hash := md5.Sum(data)
+	md5_text := hex.EncodeToString(md5_hash[:])
+
+	name := contentName(uri, contentType)
+	chunking := config.ChunkingFor(name)
+
+	if strings.EqualFold(indexed.Revision, md5_text) && chunking.matches(indexed.Chunking) {
+		return fileUnchanged, 0, nil
+	}
+
+	cli.Infof("Indexing %s...", uri)
 
-		cli.Infof("Indexing %s...", r.URI)
+	text := string(data)
 
-		// extraction, err := client.Extractions.New(ctx, wingman.ExtractionRequest{
-		// 	Name:   name,
-		// 	Reader: bytes.NewReader(data),
-		// })
+	var pages []int
 
-		// if err != nil {
-		// 	result = errors.Join(result, err)
-		// 	return nil
-		// }
+	// plain text is chunked as is, everything else is converted to text
+	// like files are
+	extracted := needsExtraction(name, contentType)
 
-		segments, err := client.Segments.New(ctx, wingman.SegmentRequest{
-			Name:   "content.txt",
-			Reader: strings.NewReader(string(data)),
+	if extracted {
+		var err error
 
-			SegmentLength:  wingman.Ptr(3000),
-			SegmentOverlap: wingman.Ptr(1500),
-		})
+		text, err = extractText(ctx, client, name, data)
 
 		if err != nil {
-			result = errors.Join(result, err)
-			continue
+			return fileUnchanged, 0, err
 		}
 
-		var documents []index.Document
+		text, pages = splitPages(text)
+	}
+
+	chunks, err := chunkText(ctx, client, name, text, chunking)
+
+	if err != nil {
+		return fileUnchanged, 0, err
+	}
+
+	metadata["revision"] = md5_text
 
-		for i, segment := range segments {
-			document := index.Document{
-				Content: segment.Text,
+	documents := chunkDocuments(uri, chunks, chunking, metadata)
 
-				Metadata: map[string]string{
-					"uri": r.URI,
+	addPages(documents, pages, name)
 
-					"index":    fmt.Sprintf("%d", i),
-					"revision": md5_text,
-				},
-			}
+	if extracted && !extract.Plain(name) {
+		dropLines(documents)
+	}
+
+	if err := i.Replace(ctx, uri, documents...); err != nil {
+		return fileUnchanged, 0, err
+	}
 
-			documents = append(documents, document)
+	if indexed.Name == "" {
+		return fileAdded, len(documents), nil
+	}
+
+	return fileUpdated, len(documents), nil
+}
+
+func templateResource(templates []resource.Template, uri string) (resource.Resource, error) {
+	for _, t := range templates {
+		if t.Match(uri) {
+			return t.Resource(uri), nil
 		}
+	}
 
-		if err := i.Index(ctx, documents...); err != nil {
-			result = errors.Join(result, err)
-			continue
+	return resource.Resource{}, errors.New("no resource template matches " + uri)
+}
+
+// contentName returns a file name for content whose extension selects its
+// extraction and chunking, taken from its uri or else its content type.
+func contentName(uri, contentType string) string {
+	contentType, _, _ = mime.ParseMediaType(contentType)
+
+	// web pages often have no or misleading extensions (e.g. .php)
+	if contentType == "text/html" || contentType == "application/xhtml+xml" {
+		return "index.html"
+	}
+
+	if u, err := url.Parse(uri); err == nil {
+		if name := path.Base(u.Path); path.Ext(name) != "" {
+			return name
 		}
 	}
 
-	return result
+	switch contentType {
+	case "text/markdown":
+		return "content.md"
+	case "text/csv":
+		return "content.csv"
+	}
+
+	if isText(contentType) {
+		return "content.txt"
+	}
+
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		return "content" + exts[0]
+	}
+
+	return "content"
+}
+
+// needsExtraction reports whether content named name is converted to text
+// before chunking: documents that can be extracted locally and anything
+// that is not text. The chunking strategy does not matter.
+func needsExtraction(name, contentType string) bool {
+	return extract.Supported(name) || !isText(contentType)
+}
+
+func isText(contentType string) bool {
+	contentType, _, _ = mime.ParseMediaType(contentType)
+
+	if contentType == "" || strings.HasPrefix(contentType, "text/") {
+		return true
+	}
+
+	switch contentType {
+	case "application/json", "application/xml", "application/yaml", "application/x-yaml", "application/javascript":
+		return true
+	}
+
+	return strings.HasSuffix(contentType, "+json") || strings.HasSuffix(contentType, "+xml")
 }
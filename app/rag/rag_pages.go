@@ -0,0 +1,89 @@
+package rag
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adrianliechti/wingman-cli/pkg/extract"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+)
+
+// splitPages replaces the page breaks in text by line breaks and returns the
+// first line of each page, or nil if text has no page breaks. Presentations
+// extracted locally always have them; pages of documents extracted by the
+// server, e.g. pdfs, are only known if the server separates them by form
+// feeds.
+func splitPages(text string) (string, []int) {
+	if !strings.Contains(text, extract.PageBreak) {
+		return text, nil
+	}
+
+	var buf strings.Builder
+
+	pages := []int{1}
+	line := 1
+
+	for _, r := range text {
+		switch r {
+		case '\n':
+			line++
+
+		case '\f':
+			r = '\n'
+			line++
+
+			pages = append(pages, line)
+		}
+
+		buf.WriteRune(r)
+	}
+
+	return buf.String(), pages
+}
+
+// pageOf returns the page (1-based) containing line.
+func pageOf(pages []int, line int) int {
+	return sort.Search(len(pages), func(i int) bool {
+		return pages[i] > line
+	})
+}
+
+// addPages records the pages, or slides for presentations, spanned by the
+// lines of each document.
+func addPages(documents []index.Document, pages []int, path string) {
+	if len(pages) == 0 {
+		return
+	}
+
+	kind := "page"
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pptx", ".ppt", ".odp", ".key":
+		kind = "slide"
+	}
+
+	for _, d := range documents {
+		start, err1 := strconv.Atoi(d.Metadata["line_start"])
+		end, err2 := strconv.Atoi(d.Metadata["line_end"])
+
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		d.Metadata[kind+"_start"] = fmt.Sprintf("%d", pageOf(pages, start))
+		d.Metadata[kind+"_end"] = fmt.Sprintf("%d", pageOf(pages, end))
+	}
+}
+
+// dropLines removes the line ranges of documents chunked from extracted text,
+// as they do not refer to lines of the original file. Pages must be added
+// before, they are derived from the lines.
+func dropLines(documents []index.Document) {
+	for _, d := range documents {
+		delete(d.Metadata, "line_start")
+		delete(d.Metadata, "line_end")
+	}
+}
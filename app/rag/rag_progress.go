@@ -0,0 +1,98 @@
+package rag
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const progressWidth = 30
+
+type progress struct {
+	mu sync.Mutex
+
+	total int
+	done  int
+
+	started time.Time
+	tty     bool
+}
+
+func newProgress(total int) *progress {
+	tty := false
+
+	if fi, err := os.Stderr.Stat(); err == nil {
+		tty = fi.Mode()&os.ModeCharDevice != 0
+	}
+
+	return &progress{
+		total: total,
+
+		started: time.Now(),
+		tty:     tty,
+	}
+}
+
+// Logf prints a message above the progress bar. Calls on a nil progress are
+// discarded, which keeps background indexing silent.
+func (p *progress) Logf(format string, a ...any) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clear()
+	fmt.Fprintf(os.Stderr, format+"\n", a...)
+	p.render()
+}
+
+func (p *progress) Increment() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	p.render()
+}
+
+func (p *progress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clear()
+}
+
+func (p *progress) clear() {
+	if !p.tty || p.total == 0 {
+		return
+	}
+
+	fmt.Fprint(os.Stderr, "\r\033[K")
+}
+
+func (p *progress) render() {
+	if !p.tty || p.total == 0 {
+		return
+	}
+
+	filled := progressWidth * p.done / p.total
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressWidth-filled)
+
+	eta := "--"
+
+	if p.done > 0 && p.done < p.total {
+		elapsed := time.Since(p.started)
+		remaining := elapsed / time.Duration(p.done) * time.Duration(p.total-p.done)
+
+		eta = remaining.Round(time.Second).String()
+	}
+
+	if p.done >= p.total {
+		eta = "0s"
+	}
+
+	fmt.Fprintf(os.Stderr, "\r\033[K%s %d/%d files, ETA %s", bar, p.done, p.total, eta)
+}
@@ -0,0 +1,85 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+const DefaultTopK = 5
+
+type QueryResult struct {
+	Source string  `json:"source"`
+	Score  float32 `json:"score"`
+
+	Content  string            `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Query prints the chunks of the index of the directory in the options most
+// similar to query.
+func Query(ctx context.Context, client *wingman.Client, query string, topK int, asJSON bool, options *Options) error {
+	query = strings.TrimSpace(query)
+
+	if query == "" {
+		return errors.New("query is required")
+	}
+
+	if topK <= 0 {
+		topK = DefaultTopK
+	}
+
+	root, err := RootDir(options)
+
+	if err != nil {
+		return err
+	}
+
+	i, err := OpenExistingIndex(client, root, options)
+
+	if err != nil {
+		return err
+	}
+
+	results, err := i.Query(ctx, query, &index.QueryOptions{
+		Limit: &topK,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	output := make([]QueryResult, 0, len(results))
+
+	for _, r := range results {
+		output = append(output, QueryResult{
+			Source: r.Source,
+			Score:  r.Score,
+
+			Content:  r.Content,
+			Metadata: r.Metadata,
+		})
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(output)
+	}
+
+	for n, r := range output {
+		fmt.Printf("%d. %s (score %.3f)\n\n", n+1, r.Source, r.Score)
+		fmt.Println(strings.TrimSpace(r.Content))
+		fmt.Println()
+	}
+
+	return nil
+}
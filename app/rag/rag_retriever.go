@@ -0,0 +1,82 @@
+package rag
+
+import (
+	"context"
+	"errors"
+
+	"github.com/adrianliechti/wingman-cli/app"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+	"github.com/adrianliechti/wingman-cli/pkg/tool/retriever"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+func newRetriever(ctx context.Context, client *wingman.Client, i *index.Index, root string, config *Config, options *Options) (*retriever.Retriever, error) {
+	if config == nil {
+		config = defaultConfig(new(Config))
+	}
+
+	rerank := config.Retrieval.Rerank
+	diversity := config.Retrieval.Diversity
+
+	queries := config.Retrieval.Queries
+	hyde := config.Retrieval.HyDE
+
+	if options != nil {
+		if options.Rerank != "" {
+			rerank = options.Rerank
+		}
+
+		if options.Diversity != nil {
+			diversity = options.Diversity
+		}
+
+		if options.Queries > 0 {
+			queries = options.Queries
+		}
+
+		hyde = hyde || options.HyDE
+	}
+
+	o := &retriever.Options{
+		Root: root,
+
+		Limit:      config.Retrieval.Limit,
+		Candidates: config.Retrieval.Candidates,
+	}
+
+	if diversity != nil {
+		o.Diversity = *diversity
+	}
+
+	if *config.Retrieval.Rewrite || queries > 0 || hyde {
+		model := config.Retrieval.RewriteModel
+
+		if model == "" {
+			model = app.DefaultModelMini
+		}
+
+		o.Rewriter = retriever.NewLLMRewriter(client, model, &retriever.RewriterOptions{
+			Queries: queries,
+			HyDE:    hyde,
+		})
+	}
+
+	switch rerank {
+	case "", "none":
+	case "server":
+		o.Reranker = retriever.NewServerReranker(app.MustRerankService(ctx), config.Retrieval.RerankModel)
+	case "llm":
+		model := config.Retrieval.RerankModel
+
+		if model == "" {
+			model = app.DefaultModelMini
+		}
+
+		o.Reranker = retriever.NewLLMReranker(client, model)
+	default:
+		return nil, errors.New("invalid reranker: " + rerank)
+	}
+
+	return retriever.New(i, o), nil
+}
@@ -0,0 +1,59 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"syscall"
+	"time"
+)
+
+const (
+	retryAttempts = 4
+	retryDelay    = time.Second
+)
+
+var transientStatus = regexp.MustCompile(`\b(408|425|429|500|502|503|504)\b`)
+
+// retry runs fn until it succeeds, fails with a permanent error or the
+// attempts are exhausted, backing off exponentially between attempts.
+func retry[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	delay := retryDelay
+
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+
+		if err == nil || attempt >= retryAttempts || !isTransient(err) {
+			return result, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+	}
+}
+
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var netErr net.Error
+
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	// the wingman client reports http failures by their status text
+	return transientStatus.MatchString(err.Error())
+}
@@ -0,0 +1,96 @@
+package rag
+
+import (
+	"context"
+
+	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/app"
+	"github.com/adrianliechti/wingman-cli/pkg/bridge"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+const DefaultServeAddr = bridge.DefaultAddr
+
+// Serve publishes the index of the directory in the options over MCP, with
+// the retrieval tools and a resource per indexed source. The vectors and
+// resources are loaded once at startup: changes made by rag index or rag
+// add-url are only served after a restart. Over stdio nothing else is
+// written to stdout.
+func Serve(ctx context.Context, client *wingman.Client, addr string, stdio bool, options *Options) error {
+	root, err := RootDir(options)
+
+	if err != nil {
+		return err
+	}
+
+	instructions, err := app.ParseInstructions()
+
+	if err != nil {
+		return err
+	}
+
+	if instructions == "" {
+		instructions = DefaultPrompt
+	}
+
+	i, err := OpenExistingIndex(client, root, options)
+
+	if err != nil {
+		return err
+	}
+
+	if err := i.CheckModel(); err != nil {
+		return err
+	}
+
+	config, err := LoadConfig(root)
+
+	if err != nil {
+		return err
+	}
+
+	r, err := newRetriever(ctx, client, i, root, config, options)
+
+	if err != nil {
+		return err
+	}
+
+	tools, err := r.Tools(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	resources, err := r.Resources(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if !stdio {
+		if addr == "" {
+			addr = DefaultServeAddr
+		}
+
+		cli.Info()
+		cli.Info("🖥️ Wingman RAG Server")
+		cli.Info()
+
+		for _, tool := range tools {
+			cli.Info("🛠️ " + tool.Name)
+		}
+
+		cli.Info()
+		cli.Infof("Serving %d sources on http://%s/sse", len(resources), addr)
+		cli.Info("Restart the server to serve changes to the index")
+		cli.Info()
+	}
+
+	return bridge.Run(ctx, client, instructions, tools, &bridge.Options{
+		Addr:  addr,
+		Stdio: stdio,
+
+		Resources: resources,
+	})
+}
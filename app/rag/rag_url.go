@@ -0,0 +1,126 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/pkg/crawl"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+const kindURL = "url"
+
+// AddURL crawls a web site, or the pages listed by a sitemap, starting at
+// url and adds the pages to the index of the directory in the options. Pages
+// whose content did not change since the last crawl are skipped, pages that
+// fail to load are reported as warnings.
+func AddURL(ctx context.Context, client *wingman.Client, url string, options *Options, crawlOptions *crawl.Options) error {
+	if options == nil {
+		options = new(Options)
+	}
+
+	root, err := RootDir(options)
+
+	if err != nil {
+		return err
+	}
+
+	path, err := IndexPath(root, options)
+
+	if err != nil {
+		return err
+	}
+
+	i, err := OpenIndex(client, path, options)
+
+	if err != nil {
+		return err
+	}
+
+	if err := i.CheckModel(); err != nil {
+		return err
+	}
+
+	config, err := LoadConfig(root)
+
+	if err != nil {
+		return err
+	}
+
+	summary, err := IndexURL(ctx, client, i, config, url, crawlOptions)
+
+	if summary != nil {
+		cli.Infof("Indexed %s", summary)
+	}
+
+	return err
+}
+
+// IndexURL crawls url and indexes the pages found.
+func IndexURL(ctx context.Context, client *wingman.Client, i *index.Index, config *Config, url string, options *crawl.Options) (*Summary, error) {
+	sources, err := i.WriteSources(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := make(map[string]index.Source)
+
+	for _, s := range sources {
+		if s.Kind == kindURL {
+			indexed[s.Name] = s
+		}
+	}
+
+	summary := &Summary{}
+
+	visit := func(ctx context.Context, page crawl.Page) error {
+		state, chunks, err := indexContent(ctx, client, i, config, page.URL, page.ContentType, page.Data, indexed[page.URL], map[string]string{
+			"url":  page.URL,
+			"kind": kindURL,
+		})
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+
+			summary.Failed++
+			cli.Warn(fmt.Errorf("%s: %w", page.URL, err))
+
+			return nil
+		}
+
+		summary.Chunks += chunks
+
+		switch state {
+		case fileAdded:
+			summary.Added++
+		case fileUpdated:
+			summary.Updated++
+		default:
+			summary.Unchanged++
+		}
+
+		return nil
+	}
+
+	failed := func(url string, err error) {
+		summary.Failed++
+		cli.Warn(fmt.Errorf("%s: %w", url, err))
+	}
+
+	if err := crawl.New(options).Crawl(ctx, url, visit, failed); err != nil {
+		return summary, err
+	}
+
+	if summary.Added+summary.Updated+summary.Unchanged == 0 && summary.Failed == 0 {
+		return summary, errors.New("no pages found at " + url)
+	}
+
+	return summary, nil
+}
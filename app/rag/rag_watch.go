@@ -0,0 +1,154 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/adrianliechti/go-cli"
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+const DefaultWatchInterval = 5 * time.Second
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// Watch polls root for changed, added and removed files and updates the
+// index until ctx is done. Polling keeps it independent of platform specific
+// file notification APIs.
+func Watch(ctx context.Context, client *wingman.Client, i *index.Index, root string, config *Config, interval time.Duration) error {
+	if config == nil {
+		config = defaultConfig(new(Config))
+	}
+
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	stamps, _ := snapshotFiles(root, config)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, files := snapshotFiles(root, config)
+
+		failed, err := syncFiles(ctx, client, i, config, stamps, current, files)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			cli.Warn(err)
+		}
+
+		// keep the previous state of failed files so they are retried
+		for _, path := range failed {
+			if stamp, ok := stamps[path]; ok {
+				current[path] = stamp
+			} else {
+				delete(current, path)
+			}
+		}
+
+		stamps = current
+	}
+}
+
+func snapshotFiles(root string, config *Config) (map[string]fileStamp, map[string]file) {
+	list, _ := collectFiles(root, config)
+
+	stamps := make(map[string]fileStamp, len(list))
+	files := make(map[string]file, len(list))
+
+	for _, f := range list {
+		info, err := os.Stat(f.path)
+
+		if err != nil {
+			continue
+		}
+
+		stamps["/"+f.rel] = fileStamp{
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		}
+
+		files["/"+f.rel] = f
+	}
+
+	return stamps, files
+}
+
+func syncFiles(ctx context.Context, client *wingman.Client, i *index.Index, config *Config, previous, current map[string]fileStamp, files map[string]file) ([]string, error) {
+	var changed []file
+	var deleted []string
+
+	for path, stamp := range current {
+		if prev, ok := previous[path]; ok && prev == stamp {
+			continue
+		}
+
+		changed = append(changed, files[path])
+	}
+
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			deleted = append(deleted, path)
+		}
+	}
+
+	if len(changed) == 0 && len(deleted) == 0 {
+		return nil, nil
+	}
+
+	var failed []string
+	var result error
+
+	if len(changed) > 0 {
+		sources, err := i.WriteSources(ctx)
+
+		if err != nil {
+			for _, f := range changed {
+				failed = append(failed, "/"+f.rel)
+			}
+
+			return failed, err
+		}
+
+		indexed := make(map[string]index.Source, len(sources))
+
+		for _, s := range sources {
+			indexed[s.Name] = s
+		}
+
+		for _, f := range changed {
+			if _, _, err := indexFile(ctx, client, i, config, f, indexed["/"+f.rel], nil); err != nil {
+				failed = append(failed, "/"+f.rel)
+				result = errors.Join(result, fmt.Errorf("/%s: %w", f.rel, err))
+			}
+		}
+	}
+
+	if len(deleted) > 0 {
+		if err := i.DeleteSources(ctx, deleted...); err != nil {
+			failed = append(failed, deleted...)
+			result = errors.Join(result, err)
+		}
+	}
+
+	return failed, result
+}
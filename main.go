@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/adrianliechti/wingman-cli/app"
 	"github.com/adrianliechti/wingman-cli/app/agent"
@@ -12,10 +15,13 @@ import (
 	"github.com/adrianliechti/wingman-cli/app/chat"
 	"github.com/adrianliechti/wingman-cli/app/coder"
 	"github.com/adrianliechti/wingman-cli/app/complete"
+	"github.com/adrianliechti/wingman-cli/app/index"
 	"github.com/adrianliechti/wingman-cli/app/rag"
+	"github.com/adrianliechti/wingman-cli/pkg/crawl"
 
 	"github.com/adrianliechti/go-cli"
 	"github.com/joho/godotenv"
+	ucli "github.com/urfave/cli/v3"
 
 	wingman "github.com/adrianliechti/wingman/pkg/client"
 )
@@ -28,9 +34,24 @@ func main() {
 	ctx := context.Background()
 
 	client := app.MustClient(ctx)
-	app := initApp(client)
+	cmd := initApp(client)
 
-	if err := app.Run(ctx, os.Args); err != nil {
+	// shut down MCP servers started by the command when interrupted
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+		<-signals
+
+		app.CloseMCP()
+		os.Exit(1)
+	}()
+
+	err := cmd.Run(ctx, os.Args)
+
+	app.CloseMCP()
+
+	if err != nil {
 		panic(err)
 	}
 }
@@ -88,8 +109,328 @@ func initApp(client *wingman.Client) cli.Command {
 
 				HideHelp: true,
 
+				Flags: append(append(append(indexFlags(), embeddingFlags()...), retrievalFlags()...),
+					&cli.IntFlag{
+						Name:  "concurrency",
+						Usage: "number of files indexed in parallel",
+					},
+
+					&cli.BoolFlag{
+						Name:  "watch",
+						Usage: "re-index changed files in the background",
+					},
+
+					&cli.BoolFlag{
+						Name:  "history",
+						Usage: "index the commits of the git repository",
+					},
+				),
+
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					return rag.Run(ctx, client, app.DefaultModel)
+					options := embeddingOptions(cmd)
+					options.Concurrency = cmd.Int("concurrency")
+					options.Watch = cmd.Bool("watch")
+					options.History = cmd.Bool("history")
+
+					retrievalOptions(cmd, options)
+
+					return rag.Run(ctx, client, app.DefaultModel, options)
+				},
+
+				Commands: []*cli.Command{
+					{
+						Name:      "index",
+						Usage:     "index a directory without starting a chat",
+						ArgsUsage: "[dir]",
+
+						Flags: append(append(indexFlags(), embeddingFlags()...),
+							&cli.IntFlag{
+								Name:  "concurrency",
+								Usage: "number of files indexed in parallel",
+							},
+
+							&cli.BoolFlag{
+								Name:  "history",
+								Usage: "index the commits of the git repository",
+							},
+						),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							options := embeddingOptions(cmd)
+							options.Concurrency = cmd.Int("concurrency")
+							options.History = cmd.Bool("history")
+
+							if dir := cmd.Args().First(); dir != "" {
+								options.Dir = dir
+							}
+
+							return rag.Index(ctx, client, options)
+						},
+					},
+
+					{
+						Name:      "query",
+						Usage:     "print the most relevant chunks for a query",
+						ArgsUsage: "<query>",
+
+						Flags: append(append(indexFlags(), embeddingFlags()...),
+							&cli.IntFlag{
+								Name:  "top-k",
+								Usage: "number of results",
+								Value: rag.DefaultTopK,
+							},
+
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "print results as json",
+							},
+						),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							query := strings.Join(cmd.Args().Slice(), " ")
+							return rag.Query(ctx, client, query, cmd.Int("top-k"), cmd.Bool("json"), embeddingOptions(cmd))
+						},
+					},
+
+					{
+						Name:      "ask",
+						Usage:     "answer a single question from the index",
+						ArgsUsage: "<question>",
+
+						Flags: append(append(indexFlags(), embeddingFlags()...), retrievalFlags()...),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							question := strings.Join(cmd.Args().Slice(), " ")
+
+							options := embeddingOptions(cmd)
+							retrievalOptions(cmd, options)
+
+							return rag.Ask(ctx, client, app.DefaultModel, question, options)
+						},
+					},
+
+					{
+						Name:      "eval",
+						Usage:     "measure retrieval quality against a set of questions",
+						ArgsUsage: "<questions.yaml>",
+
+						Flags: append(append(append(indexFlags(), embeddingFlags()...), retrievalFlags()...),
+							&cli.IntFlag{
+								Name:  "top-k",
+								Usage: "number of chunks retrieved per question",
+							},
+
+							&cli.BoolFlag{
+								Name:  "judge",
+								Usage: "generate answers and grade them with a model",
+							},
+
+							&cli.StringFlag{
+								Name:  "judge-model",
+								Usage: "model grading the answers",
+							},
+
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "print the report as json",
+							},
+						),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							options := embeddingOptions(cmd)
+							retrievalOptions(cmd, options)
+
+							return rag.Eval(ctx, client, app.DefaultModel, cmd.Args().First(), options, &rag.EvalOptions{
+								TopK: cmd.Int("top-k"),
+
+								Judge:      cmd.Bool("judge"),
+								JudgeModel: cmd.String("judge-model"),
+
+								JSON: cmd.Bool("json"),
+							})
+						},
+					},
+
+					{
+						Name:  "serve",
+						Usage: "publish the index as an MCP server; restart it to serve re-indexed content",
+
+						Flags: append(append(append(indexFlags(), embeddingFlags()...), retrievalFlags()...),
+							&cli.StringFlag{
+								Name:  "addr",
+								Usage: "address to listen on",
+								Value: rag.DefaultServeAddr,
+							},
+
+							&cli.BoolFlag{
+								Name:  "stdio",
+								Usage: "serve over stdin and stdout instead of http",
+							},
+						),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							options := embeddingOptions(cmd)
+							retrievalOptions(cmd, options)
+
+							return rag.Serve(ctx, client, cmd.String("addr"), cmd.Bool("stdio"), options)
+						},
+					},
+
+					{
+						Name:      "add-url",
+						Usage:     "crawl a web site or sitemap and add its pages to the index",
+						ArgsUsage: "<url>",
+
+						Flags: append(append(indexFlags(), embeddingFlags()...),
+							&cli.IntFlag{
+								Name:  "depth",
+								Usage: "number of links to follow from the start page",
+								Value: crawl.DefaultDepth,
+							},
+
+							&cli.IntFlag{
+								Name:  "max-pages",
+								Usage: "maximum number of pages to index",
+								Value: crawl.DefaultMaxPages,
+							},
+
+							&cli.StringFlag{
+								Name:  "prefix",
+								Usage: "only follow links whose path starts with this prefix (default: directory of the url)",
+							},
+						),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							url := cmd.Args().First()
+
+							if url == "" {
+								return errors.New("url is required")
+							}
+
+							depth := cmd.Int("depth")
+
+							return rag.AddURL(ctx, client, url, embeddingOptions(cmd), &crawl.Options{
+								Depth:    &depth,
+								MaxPages: cmd.Int("max-pages"),
+
+								Prefix: cmd.String("prefix"),
+							})
+						},
+					},
+				},
+			},
+
+			{
+				Name:  "index",
+				Usage: "RAG Index",
+
+				HideHelp: true,
+
+				Commands: []*cli.Command{
+					{
+						Name:  "stats",
+						Usage: "show document and chunk counts",
+
+						Flags: indexFlags(),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return index.Stats(ctx, client, indexOptions(cmd))
+						},
+					},
+
+					{
+						Name:  "list",
+						Usage: "list indexed sources with their revisions",
+
+						Flags: indexFlags(),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return index.List(ctx, client, indexOptions(cmd))
+						},
+					},
+
+					{
+						Name:      "inspect",
+						Usage:     "print the chunks of a source",
+						ArgsUsage: "<source>",
+
+						Flags: indexFlags(),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return index.Inspect(ctx, client, cmd.Args().First(), indexOptions(cmd))
+						},
+					},
+
+					{
+						Name:      "purge",
+						Usage:     "delete sources from the index",
+						ArgsUsage: "<source>...",
+
+						Flags: indexFlags(),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return index.Purge(ctx, client, cmd.Args().Slice(), indexOptions(cmd))
+						},
+					},
+
+					{
+						Name:      "export",
+						Usage:     "write documents and vectors as jsonl",
+						ArgsUsage: "[file]",
+
+						Flags: indexFlags(),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return index.Export(ctx, client, cmd.Args().First(), indexOptions(cmd))
+						},
+					},
+
+					{
+						Name:      "import",
+						Usage:     "add documents and vectors from a jsonl export",
+						ArgsUsage: "<file>",
+
+						Flags: append(append(indexFlags(), embeddingFlags()...),
+							&cli.BoolFlag{
+								Name:  "reembed",
+								Usage: "compute vectors again instead of importing them",
+							},
+						),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return index.Import(ctx, client, cmd.Args().First(), cmd.Bool("reembed"), embeddingOptions(cmd))
+						},
+					},
+
+					{
+						Name:  "vacuum",
+						Usage: "reclaim unused space in the database",
+
+						Flags: indexFlags(),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return index.Vacuum(ctx, client, indexOptions(cmd))
+						},
+					},
+
+					{
+						Name:  "reembed",
+						Usage: "re-embed all documents with the configured embedding model",
+
+						Flags: append(append(indexFlags(), embeddingFlags()...),
+							&cli.BoolFlag{
+								Name:  "no-cache",
+								Usage: "do not reuse cached embeddings, e.g. after the server's default model changed",
+							},
+						),
+
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							options := embeddingOptions(cmd)
+							options.NoCache = cmd.Bool("no-cache")
+
+							return index.Reembed(ctx, client, options)
+						},
+					},
 				},
 			},
 
@@ -129,6 +470,95 @@ func initApp(client *wingman.Client) cli.Command {
 	}
 }
 
+func embeddingFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "embedding-model",
+			Usage: "embedding model used to index and query documents",
+			Value: app.EmbeddingModel,
+		},
+
+		&cli.IntFlag{
+			Name:  "batch-size",
+			Usage: "number of texts per embedding request",
+			Value: rag.DefaultBatchSize,
+		},
+	}
+}
+
+func retrievalFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "rerank",
+			Usage: "rerank retrieved chunks (server, llm or none)",
+		},
+
+		&ucli.FloatFlag{
+			Name:  "diversity",
+			Usage: "spread results across passages and sources, between 0 (off) and 1",
+		},
+
+		&cli.IntFlag{
+			Name:  "queries",
+			Usage: "number of additional sub-queries per question",
+		},
+
+		&cli.BoolFlag{
+			Name:  "hyde",
+			Usage: "also search for a hypothetical answer",
+		},
+	}
+}
+
+func retrievalOptions(cmd *cli.Command, options *rag.Options) {
+	options.Rerank = cmd.String("rerank")
+
+	if cmd.IsSet("diversity") {
+		diversity := cmd.Float("diversity")
+		options.Diversity = &diversity
+	}
+
+	options.Queries = cmd.Int("queries")
+	options.HyDE = cmd.Bool("hyde")
+}
+
+func indexFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "dir",
+			Usage: "directory whose index, config and MCP servers are used (default: working directory)",
+		},
+
+		&cli.StringFlag{
+			Name:  "index",
+			Usage: "path of the index database (default: per directory in the user cache dir)",
+		},
+
+		&cli.StringSliceFlag{
+			Name:  "collection",
+			Usage: "collection within the index; repeat to query several, the first one is indexed",
+		},
+	}
+}
+
+func indexOptions(cmd *cli.Command) *rag.Options {
+	return &rag.Options{
+		Dir: cmd.String("dir"),
+
+		Index:       cmd.String("index"),
+		Collections: cmd.StringSlice("collection"),
+	}
+}
+
+func embeddingOptions(cmd *cli.Command) *rag.Options {
+	options := indexOptions(cmd)
+
+	options.EmbeddingModel = cmd.String("embedding-model")
+	options.BatchSize = cmd.Int("batch-size")
+
+	return options
+}
+
 func readInput() string {
 	fi, err := os.Stdin.Stat()
 
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/adrianliechti/wingman-cli/pkg/markdown"
@@ -40,48 +41,96 @@ func Run(ctx context.Context, client *wingman.Client, model, instructions string
 
 		input.Messages = append(input.Messages, wingman.UserMessage(prompt))
 
-		var message *wingman.Message
+		message, err := complete(ctx, client, &input, tools, true)
 
-		for {
-			var completion *wingman.Completion
+		if err != nil {
+			return err
+		}
 
-			fn := func() error {
-				completion, err = client.Completions.New(ctx, input)
-				return err
-			}
+		if message == nil {
+			return nil
+		}
 
-			if err := cli.Run("Thinking...", fn); err != nil {
-				return err
-			}
+		markdown.Render(os.Stdout, message.Text())
+	}
+
+	return nil
+}
 
-			message = completion.Message
-			input.Messages = append(input.Messages, *message)
+// Ask answers a single prompt, calling tools as needed, and returns the final message text.
+func Ask(ctx context.Context, client *wingman.Client, model, instructions, prompt string, tools []tool.Tool) (string, error) {
+	input := wingman.CompletionRequest{
+		Model: model,
 
-			calls := message.ToolCalls()
+		CompleteOptions: wingman.CompleteOptions{
+			Tools: util.ConvertTools(tools),
+		},
+	}
 
-			if len(calls) == 0 {
-				break
-			}
+	if instructions != "" {
+		input.Messages = append(input.Messages, wingman.SystemMessage(instructions))
+	}
 
-			for _, call := range calls {
-				content, err := handleToolCall(ctx, tools, call)
+	input.Messages = append(input.Messages, wingman.UserMessage(prompt))
 
-				if err != nil {
-					content = err.Error()
-				}
+	message, err := complete(ctx, client, &input, tools, false)
 
-				input.Messages = append(input.Messages, wingman.ToolMessage(call.ID, content))
-			}
+	if err != nil {
+		return "", err
+	}
+
+	if message == nil {
+		return "", nil
+	}
+
+	return message.Text(), nil
+}
+
+func complete(ctx context.Context, client *wingman.Client, input *wingman.CompletionRequest, tools []tool.Tool, interactive bool) (*wingman.Message, error) {
+	var message *wingman.Message
+
+	for {
+		var completion *wingman.Completion
+		var err error
+
+		fn := func() error {
+			completion, err = client.Completions.New(ctx, *input)
+			return err
 		}
 
-		if message == nil {
-			return nil
+		if interactive {
+			err = cli.Run("Thinking...", fn)
+		} else {
+			err = fn()
 		}
 
-		markdown.Render(os.Stdout, message.Text())
+		if err != nil {
+			return nil, err
+		}
+
+		message = completion.Message
+		input.Messages = append(input.Messages, *message)
+
+		calls := message.ToolCalls()
+
+		if len(calls) == 0 {
+			break
+		}
+
+		history := slices.Clone(input.Messages)
+
+		for _, call := range calls {
+			content, err := handleToolCall(tool.WithMessages(ctx, history), tools, call)
+
+			if err != nil {
+				content = err.Error()
+			}
+
+			input.Messages = append(input.Messages, wingman.ToolMessage(call.ID, content))
+		}
 	}
 
-	return nil
+	return message, nil
 }
 
 func handleToolCall(ctx context.Context, tools []tool.Tool, call wingman.ToolCall) (string, error) {
@@ -3,18 +3,37 @@ package bridge
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/rs/cors"
 
+	"github.com/adrianliechti/wingman-cli/pkg/resource"
 	"github.com/adrianliechti/wingman-cli/pkg/tool"
 	wingman "github.com/adrianliechti/wingman/pkg/client"
 )
 
-func Run(ctx context.Context, client *wingman.Client, instructions string, tools []tool.Tool) error {
+const DefaultAddr = "localhost:4200"
+
+type Options struct {
+	// Addr to listen on, defaults to DefaultAddr
+	Addr string
+
+	// Stdio serves a single client over stdin and stdout instead of http
+	Stdio bool
+
+	Resources []resource.Resource
+}
+
+func Run(ctx context.Context, client *wingman.Client, instructions string, tools []tool.Tool, options *Options) error {
+	if options == nil {
+		options = new(Options)
+	}
+
 	impl := &mcp.Implementation{
 		Name: "wingman",
 
@@ -91,7 +110,51 @@ func Run(ctx context.Context, client *wingman.Client, instructions string, tools
 		s.AddTool(tool, handler)
 	}
 
-	addr := "localhost:4200"
+	for _, r := range options.Resources {
+		if u, err := url.Parse(r.URI); err != nil || !u.IsAbs() {
+			return errors.New("invalid resource uri: " + r.URI)
+		}
+
+		handler := func(ctx context.Context, session *mcp.ServerSession, params *mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+			data, err := r.Content(ctx)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return &mcp.ReadResourceResult{
+				Contents: []*mcp.ResourceContents{
+					{
+						URI:      params.URI,
+						MIMEType: r.ContentType,
+
+						Text: string(data),
+					},
+				},
+			}, nil
+		}
+
+		resource := &mcp.Resource{
+			URI: r.URI,
+
+			Name:        r.Name,
+			Description: r.Description,
+
+			MIMEType: r.ContentType,
+		}
+
+		s.AddResource(resource, handler)
+	}
+
+	if options.Stdio {
+		return s.Run(ctx, mcp.NewStdioTransport())
+	}
+
+	addr := options.Addr
+
+	if addr == "" {
+		addr = DefaultAddr
+	}
 
 	mux := http.NewServeMux()
 
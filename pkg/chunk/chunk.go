@@ -0,0 +1,251 @@
+package chunk
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const DefaultSize = 3000
+
+// Chunk is a piece of a text along with its line range (1-based, inclusive),
+// the names of the declarations it contains and, for markdown, the heading
+// path it starts in.
+type Chunk struct {
+	Text string
+
+	Symbols []string
+	Section string
+
+	LineStart int
+	LineEnd   int
+}
+
+// unit is a run of lines that should stay together, such as a declaration
+// including the comments preceding it, or a paragraph.
+type unit struct {
+	start int
+	end   int
+
+	symbols []string
+	section string
+
+	// heading marks the start of a markdown section, where a new chunk
+	// preferably begins
+	heading bool
+}
+
+var languages = map[string]string{
+	".go": "go",
+
+	".py":  "python",
+	".rb":  "ruby",
+	".php": "php",
+
+	".js":  "javascript",
+	".jsx": "javascript",
+	".mjs": "javascript",
+	".ts":  "typescript",
+	".tsx": "typescript",
+
+	".java":  "java",
+	".kt":    "kotlin",
+	".scala": "scala",
+	".cs":    "csharp",
+	".swift": "swift",
+
+	".c":   "c",
+	".h":   "c",
+	".cc":  "cpp",
+	".cpp": "cpp",
+	".hpp": "cpp",
+	".rs":  "rust",
+
+	".sh": "shell",
+}
+
+// Language returns the programming language of a file by its extension, or
+// an empty string if it is not source code.
+func Language(name string) string {
+	return languages[strings.ToLower(filepath.Ext(name))]
+}
+
+// Code splits a source file into chunks of up to size bytes, aligned to
+// functions, types and classes. Go is parsed with go/ast, other languages
+// are split at top-level declarations found by a line heuristic.
+func Code(name string, text string, size, overlap int) []Chunk {
+	lines := splitLines(text)
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	var units []unit
+
+	if Language(name) == "go" {
+		units = goUnits(text, len(lines))
+	}
+
+	if units == nil {
+		units = heuristicUnits(lines)
+	}
+
+	return pack(lines, units, size, overlap)
+}
+
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// pack merges consecutive units into chunks of up to size bytes, repeating
+// up to overlap bytes of trailing units at the start of the next chunk.
+// Units larger than size are split at line boundaries.
+func pack(lines []string, units []unit, size, overlap int) []Chunk {
+	if size <= 0 {
+		size = DefaultSize
+	}
+
+	overlap = min(max(overlap, 0), size/2)
+
+	length := func(u unit) int {
+		n := 0
+
+		for _, line := range lines[u.start-1 : u.end] {
+			n += len(line)
+		}
+
+		return n
+	}
+
+	var chunks []Chunk
+
+	var current []unit
+	var currentLength int
+
+	flush := func(keep bool) {
+		if len(current) == 0 {
+			return
+		}
+
+		if c, ok := newChunk(lines, current); ok {
+			chunks = append(chunks, c)
+		}
+
+		var kept []unit
+		var keptLength int
+
+		for n := len(current) - 1; keep && n > 0; n-- {
+			l := length(current[n])
+
+			if keptLength+l > overlap {
+				break
+			}
+
+			kept = append([]unit{current[n]}, kept...)
+			keptLength += l
+		}
+
+		current = kept
+		currentLength = keptLength
+	}
+
+	for _, u := range units {
+		for _, part := range splitUnit(lines, u, size) {
+			l := length(part)
+
+			if len(current) > 0 && part.heading && currentLength > size/4 {
+				flush(false)
+			}
+
+			if len(current) > 0 && currentLength+l > size {
+				flush(true)
+			}
+
+			// drop overlap that no longer fits next to a large unit
+			for len(current) > 0 && currentLength+l > size {
+				currentLength -= length(current[0])
+				current = current[1:]
+			}
+
+			current = append(current, part)
+			currentLength += l
+		}
+	}
+
+	flush(false)
+
+	return chunks
+}
+
+// splitUnit splits a unit larger than size at line boundaries.
+func splitUnit(lines []string, u unit, size int) []unit {
+	var parts []unit
+
+	part := u
+	part.end = u.start - 1
+
+	n := 0
+
+	for line := u.start; line <= u.end; line++ {
+		l := len(lines[line-1])
+
+		if part.end >= part.start && n+l > size {
+			parts = append(parts, part)
+
+			part = u
+			part.start = line
+			part.heading = false
+
+			n = 0
+		}
+
+		part.end = line
+		n += l
+	}
+
+	return append(parts, part)
+}
+
+// newChunk joins the lines spanned by units, leaving out leading and
+// trailing blank lines.
+func newChunk(lines []string, units []unit) (Chunk, bool) {
+	start := units[0].start
+	end := units[len(units)-1].end
+
+	for start <= end && strings.TrimSpace(lines[start-1]) == "" {
+		start++
+	}
+
+	for end >= start && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+
+	if start > end {
+		return Chunk{}, false
+	}
+
+	c := Chunk{
+		Text: strings.TrimRight(strings.Join(lines[start-1:end], ""), "\r\n\t "),
+
+		Section: units[0].section,
+
+		LineStart: start,
+		LineEnd:   end,
+	}
+
+	for _, u := range units {
+		for _, s := range u.symbols {
+			if !slices.Contains(c.Symbols, s) {
+				c.Symbols = append(c.Symbols, s)
+			}
+		}
+	}
+
+	return c, true
+}
@@ -0,0 +1,251 @@
+package chunk
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLanguage(t *testing.T) {
+	tests := map[string]string{
+		"main.go":       "go",
+		"app/Main.java": "java",
+		"index.TSX":     "typescript",
+		"readme.md":     "",
+		"Makefile":      "",
+	}
+
+	for name, want := range tests {
+		if got := Language(name); got != want {
+			t.Errorf("Language(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// chunkRange is the line range and symbols of a chunk.
+type chunkRange struct {
+	start, end int
+
+	symbols []string
+}
+
+func ranges(chunks []Chunk) []chunkRange {
+	var result []chunkRange
+
+	for _, c := range chunks {
+		result = append(result, chunkRange{c.LineStart, c.LineEnd, c.Symbols})
+	}
+
+	return result
+}
+
+func equalRanges(a, b []chunkRange) bool {
+	return slices.EqualFunc(a, b, func(x, y chunkRange) bool {
+		return x.start == y.start && x.end == y.end && slices.Equal(x.symbols, y.symbols)
+	})
+}
+
+func TestCode(t *testing.T) {
+	golang := `package main
+
+import "fmt"
+
+// Hello prints a greeting.
+func Hello() {
+	fmt.Println("hello")
+}
+
+type List[T any] struct {
+	items []T
+}
+
+func (l *List[T]) Len() int {
+	return len(l.items)
+}
+
+const (
+	A = 1
+	_ = 2
+	B = 3
+)
+`
+
+	python := `import os
+
+
+@decorator
+def hello(name):
+    return name
+
+
+class Greeter:
+    def greet(self):
+        pass
+`
+
+	c := `#include <stdio.h>
+
+/* entry point */
+static int main(void)
+{
+    return 0;
+}
+`
+
+	tests := []struct {
+		name string
+
+		file string
+		text string
+		size int
+
+		want []chunkRange
+	}{
+		{
+			name: "go declarations",
+			file: "main.go",
+			text: golang,
+			size: 70,
+
+			want: []chunkRange{
+				{1, 3, nil},
+				{5, 8, []string{"Hello"}},
+				{10, 12, []string{"List"}},
+				{14, 16, []string{"List.Len"}},
+				{18, 22, []string{"A", "B"}},
+			},
+		},
+		{
+			name: "go declarations packed",
+			file: "main.go",
+			text: golang,
+			size: 1000,
+
+			want: []chunkRange{
+				{1, 22, []string{"Hello", "List", "List.Len", "A", "B"}},
+			},
+		},
+		{
+			name: "go declaration split at lines",
+			file: "main.go",
+			text: golang,
+			size: 50,
+
+			want: []chunkRange{
+				{1, 3, nil},
+				{5, 6, []string{"Hello"}},
+				{7, 8, []string{"Hello"}},
+				{10, 12, []string{"List"}},
+				{14, 14, []string{"List.Len"}},
+				{15, 16, []string{"List.Len"}},
+				{18, 22, []string{"A", "B"}},
+			},
+		},
+		{
+			name: "invalid go",
+			file: "main.go",
+			text: "package main\n\nfunc broken( {\n",
+			size: 1000,
+
+			want: []chunkRange{
+				{1, 3, []string{"broken"}},
+			},
+		},
+		{
+			name: "python decorators",
+			file: "main.py",
+			text: python,
+			size: 50,
+
+			want: []chunkRange{
+				{1, 1, nil},
+				{4, 6, []string{"hello"}},
+				{9, 11, []string{"Greeter"}},
+			},
+		},
+		{
+			name: "c function",
+			file: "main.c",
+			text: c,
+			size: 60,
+
+			want: []chunkRange{
+				{1, 1, nil},
+				{3, 7, []string{"main"}},
+			},
+		},
+		{
+			name: "empty",
+			file: "main.go",
+			text: "",
+			size: 100,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ranges(Code(test.file, test.text, test.size, 0))
+
+			if !equalRanges(got, test.want) {
+				t.Errorf("chunks %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCodeText(t *testing.T) {
+	text := "package main\n\n\nfunc A() {}\n\n\nfunc B() {}\n\n"
+
+	chunks := Code("main.go", text, 15, 0)
+
+	want := []string{"package main", "func A() {}", "func B() {}"}
+
+	var got []string
+
+	for _, c := range chunks {
+		got = append(got, c.Text)
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("texts %q, want %q", got, want)
+	}
+}
+
+func TestCodeOverlap(t *testing.T) {
+	text := "package main\n\nfunc A() {}\n\nfunc B() {}\n\nfunc C() {}\n"
+
+	tests := []struct {
+		name string
+
+		overlap int
+		want    []chunkRange
+	}{
+		{
+			name: "without overlap",
+
+			want: []chunkRange{
+				{1, 3, []string{"A"}},
+				{5, 7, []string{"B", "C"}},
+			},
+		},
+		{
+			name:    "with overlap",
+			overlap: 13,
+
+			want: []chunkRange{
+				{1, 3, []string{"A"}},
+				{3, 5, []string{"A", "B"}},
+				{5, 7, []string{"B", "C"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ranges(Code("main.go", text, 30, test.overlap))
+
+			if !equalRanges(got, test.want) {
+				t.Errorf("chunks %v, want %v", got, test.want)
+			}
+		})
+	}
+}
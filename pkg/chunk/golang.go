@@ -0,0 +1,125 @@
+package chunk
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// goUnits returns one unit per top-level declaration of a Go file, with the
+// package clause and imports as the first unit. It returns nil if the file
+// cannot be parsed.
+func goUnits(text string, lines int) []unit {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", text, parser.ParseComments|parser.SkipObjectResolution)
+
+	if err != nil {
+		return nil
+	}
+
+	var units []unit
+
+	prev := 0
+
+	for _, decl := range file.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.IMPORT {
+			continue
+		}
+
+		start := fset.Position(decl.Pos()).Line
+
+		if doc := declDoc(decl); doc != nil {
+			start = fset.Position(doc.Pos()).Line
+		}
+
+		// the package clause, imports and loose comments before the first
+		// declaration form a unit of their own
+		if start-1 > prev {
+			units = append(units, unit{
+				start: prev + 1,
+				end:   start - 1,
+			})
+		}
+
+		end := fset.Position(decl.End()).Line
+
+		units = append(units, unit{
+			start: start,
+			end:   end,
+
+			symbols: declSymbols(decl),
+		})
+
+		prev = end
+	}
+
+	if prev < lines {
+		units = append(units, unit{
+			start: prev + 1,
+			end:   lines,
+		})
+	}
+
+	return units
+}
+
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	}
+
+	return nil
+}
+
+func declSymbols(decl ast.Decl) []string {
+	var symbols []string
+
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		name := d.Name.Name
+
+		if d.Recv != nil && len(d.Recv.List) > 0 {
+			if recv := receiverName(d.Recv.List[0].Type); recv != "" {
+				name = recv + "." + name
+			}
+		}
+
+		symbols = append(symbols, name)
+
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				symbols = append(symbols, s.Name.Name)
+
+			case *ast.ValueSpec:
+				for _, name := range s.Names {
+					if name.Name != "_" {
+						symbols = append(symbols, name.Name)
+					}
+				}
+			}
+		}
+	}
+
+	return symbols
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	}
+
+	return ""
+}
@@ -0,0 +1,140 @@
+package chunk
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// declarations at the start of a line, e.g. "def", "class", "export
+	// async function", "pub fn", "public static void" or "impl"
+	declPattern = regexp.MustCompile(`^(@|#\[|(export\s+)?(default\s+)?(async\s+)?(pub(\([\w:]+\))?\s+)?((public|private|protected|internal|static|abstract|final|sealed|partial|override|virtual|extern|unsafe|inline|open|data|suspend)\s+)*(def|class|interface|struct|enum|trait|impl|fn|func|function|module|namespace|type|object|record|const|let|var|val|void|template|union|typedef|mod|macro_rules!)\b)`)
+
+	// the name following a declaration keyword
+	namePattern = regexp.MustCompile(`\b(def|class|interface|struct|enum|trait|impl|fn|func|function|module|namespace|type|object|record|const|let|var|val|union|mod)\s+([A-Za-z_$][\w$]*)`)
+
+	// a C-like function definition such as "static int main(void)"
+	funcPattern = regexp.MustCompile(`^[A-Za-z_][\w\s\*&:<>,]*?\b([A-Za-z_]\w*)\s*\([^;]*$`)
+)
+
+// heuristicUnits splits source code at lines that start a top-level
+// declaration, i.e. unindented lines that look like one. Comments and
+// decorators directly above a declaration belong to it.
+func heuristicUnits(lines []string) []unit {
+	var starts []int
+
+	for n, line := range lines {
+		if !isDeclaration(line) {
+			continue
+		}
+
+		start := n
+
+		for start > 0 && isPreamble(lines[start-1]) {
+			start--
+		}
+
+		// decorators and attributes above a declaration were already
+		// seen as declarations
+		if len(starts) > 0 && starts[len(starts)-1] >= start {
+			continue
+		}
+
+		starts = append(starts, start)
+	}
+
+	var units []unit
+
+	prev := 0
+
+	for i, start := range starts {
+		if start > prev {
+			units = append(units, unit{
+				start: prev + 1,
+				end:   start,
+			})
+		}
+
+		end := len(lines)
+
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+
+		units = append(units, unit{
+			start: start + 1,
+			end:   end,
+
+			symbols: declarationSymbols(lines[start:end]),
+		})
+
+		prev = end
+	}
+
+	if prev < len(lines) {
+		units = append(units, unit{
+			start: prev + 1,
+			end:   len(lines),
+		})
+	}
+
+	return units
+}
+
+func isDeclaration(line string) bool {
+	if line == "" || line[0] == ' ' || line[0] == '\t' {
+		return false
+	}
+
+	if declPattern.MatchString(line) {
+		return true
+	}
+
+	if strings.HasSuffix(strings.TrimSpace(line), ";") || isComment(line) {
+		return false
+	}
+
+	return funcPattern.MatchString(line)
+}
+
+func isPreamble(line string) bool {
+	if line == "" || line[0] == ' ' || line[0] == '\t' {
+		return isComment(line)
+	}
+
+	return isComment(line) || strings.HasPrefix(line, "@") || strings.HasPrefix(line, "#[")
+}
+
+func isComment(line string) bool {
+	line = strings.TrimSpace(line)
+
+	for _, prefix := range []string{"//", "/*", "*", "#", "--", `"""`} {
+		if strings.HasPrefix(line, prefix) && !strings.HasPrefix(line, "#[") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// declarationSymbols returns the name declared by the first line of a
+// declaration that is not a decorator or comment.
+func declarationSymbols(lines []string) []string {
+	for _, line := range lines {
+		if isPreamble(line) || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if m := namePattern.FindStringSubmatch(line); m != nil {
+			return []string{m[2]}
+		}
+
+		if m := funcPattern.FindStringSubmatch(line); m != nil {
+			return []string{m[1]}
+		}
+
+		break
+	}
+
+	return nil
+}
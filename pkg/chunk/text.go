@@ -0,0 +1,133 @@
+package chunk
+
+import (
+	"regexp"
+	"strings"
+)
+
+var headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+
+// Paragraphs splits text into chunks of up to size bytes at blank lines.
+func Paragraphs(text string, size, overlap int) []Chunk {
+	lines := splitLines(text)
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return pack(lines, paragraphUnits(lines), size, overlap)
+}
+
+// Markdown splits text into chunks of up to size bytes, preferably at
+// headings and otherwise at blank lines outside of code blocks. Each chunk
+// records the heading path it starts in.
+func Markdown(text string, size, overlap int) []Chunk {
+	lines := splitLines(text)
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return pack(lines, markdownUnits(lines), size, overlap)
+}
+
+func paragraphUnits(lines []string) []unit {
+	var units []unit
+
+	start := 1
+
+	for n := 1; n <= len(lines); n++ {
+		if strings.TrimSpace(lines[n-1]) != "" || n == start {
+			continue
+		}
+
+		units = append(units, unit{start: start, end: n})
+		start = n + 1
+	}
+
+	if start <= len(lines) {
+		units = append(units, unit{start: start, end: len(lines)})
+	}
+
+	return units
+}
+
+func markdownUnits(lines []string) []unit {
+	var units []unit
+
+	var headings []string
+	var fence string
+
+	current := unit{start: 1}
+
+	add := func(end int) {
+		if end >= current.start {
+			current.end = end
+			units = append(units, current)
+		}
+
+		current = unit{
+			start:   end + 1,
+			section: sectionPath(headings),
+		}
+	}
+
+	for n := 1; n <= len(lines); n++ {
+		line := strings.TrimRight(lines[n-1], "\r\n")
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			add(n - 1)
+
+			level := len(m[1])
+
+			if len(headings) >= level {
+				headings = headings[:level-1]
+			}
+
+			for len(headings) < level-1 {
+				headings = append(headings, "")
+			}
+
+			headings = append(headings, m[2])
+
+			current.section = sectionPath(headings)
+			current.heading = true
+
+			continue
+		}
+
+		if trimmed == "" && n > current.start {
+			add(n)
+		}
+	}
+
+	add(len(lines))
+
+	return units
+}
+
+func sectionPath(headings []string) string {
+	var parts []string
+
+	for _, h := range headings {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+
+	return strings.Join(parts, " > ")
+}
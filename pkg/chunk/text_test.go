@@ -0,0 +1,143 @@
+package chunk
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParagraphs(t *testing.T) {
+	text := "one\ntwo\n\nthree\n\n\nfour five six\n"
+
+	tests := []struct {
+		name string
+
+		size int
+
+		want []string
+	}{
+		{
+			name: "packed",
+			size: 100,
+
+			want: []string{"one\ntwo\n\nthree\n\n\nfour five six"},
+		},
+		{
+			name: "per paragraph",
+			size: 10,
+
+			want: []string{"one\ntwo", "three", "four five six"},
+		},
+		{
+			name: "long lines",
+			size: 5,
+
+			want: []string{"one", "two", "three", "four five six"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+
+			for _, c := range Paragraphs(text, test.size, 0) {
+				got = append(got, c.Text)
+			}
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("chunks %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParagraphsLines(t *testing.T) {
+	chunks := Paragraphs("one\ntwo\n\nthree\n\n\nfour five six\n", 10, 0)
+
+	got := ranges(chunks)
+	want := []chunkRange{{1, 2, nil}, {4, 4, nil}, {7, 7, nil}}
+
+	if !equalRanges(got, want) {
+		t.Errorf("chunks %v, want %v", got, want)
+	}
+
+	if chunks := Paragraphs("", 10, 0); chunks != nil {
+		t.Errorf("chunks of empty text %v, want none", chunks)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	text := "# Title\n\nIntro text.\n\n## Install\n\nRun it.\n\n```sh\n# not a heading\n\nmake\n```\n\n### Linux\n\nUse apt.\n\n## Usage\n\nCall it.\n"
+
+	type section struct {
+		start, end int
+
+		section string
+	}
+
+	tests := []struct {
+		name string
+
+		size int
+
+		want []section
+	}{
+		{
+			name: "packed",
+			size: 1000,
+
+			want: []section{
+				{1, 21, "Title"},
+			},
+		},
+		{
+			name: "sections",
+			size: 60,
+
+			want: []section{
+				{1, 3, "Title"},
+				{5, 13, "Title > Install"},
+				{15, 17, "Title > Install > Linux"},
+				{19, 21, "Title > Usage"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []section
+
+			for _, c := range Markdown(text, test.size, 0) {
+				got = append(got, section{c.LineStart, c.LineEnd, c.Section})
+			}
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("chunks %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSectionPath(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"# A\n\ntext\n", "A"},
+		{"# A\n\n### C\n\ntext\n", "A > C"},
+		{"# A\n\n## B\n\n# D\n\ntext\n", "D"},
+		{"## B ##\n\ntext\n", "B"},
+		{"#hashtag\n\ntext\n", ""},
+	}
+
+	for _, test := range tests {
+		chunks := Markdown(test.text, 4, 0)
+
+		if len(chunks) == 0 {
+			t.Fatalf("no chunks for %q", test.text)
+		}
+
+		if got := chunks[len(chunks)-1].Section; got != test.want {
+			t.Errorf("section of %q = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
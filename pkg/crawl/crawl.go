@@ -0,0 +1,358 @@
+package crawl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	DefaultDepth    = 2
+	DefaultMaxPages = 100
+	DefaultMaxSize  = 20 << 20
+
+	UserAgent = "wingman"
+)
+
+type Crawler struct {
+	client *http.Client
+
+	depth    int
+	maxPages int
+	maxSize  int64
+
+	prefix string
+}
+
+type Options struct {
+	Client *http.Client
+
+	// Depth is the number of links followed from the start page
+	Depth *int
+
+	// MaxPages limits the number of pages visited
+	MaxPages int
+
+	// MaxSize in bytes of a single page
+	MaxSize int64
+
+	// Prefix limits crawling to urls whose path starts with it; defaults to
+	// the directory of the start url
+	Prefix string
+}
+
+// Page is a successfully fetched document.
+type Page struct {
+	URL string
+
+	ContentType string
+	Data        []byte
+}
+
+// VisitFn is called for each page; an error stops the crawl.
+type VisitFn func(ctx context.Context, page Page) error
+
+func New(options *Options) *Crawler {
+	if options == nil {
+		options = new(Options)
+	}
+
+	c := &Crawler{
+		client: options.Client,
+
+		depth:    DefaultDepth,
+		maxPages: options.MaxPages,
+		maxSize:  options.MaxSize,
+
+		prefix: options.Prefix,
+	}
+
+	if c.client == nil {
+		c.client = &http.Client{
+			Timeout: 30 * time.Second,
+		}
+	}
+
+	if options.Depth != nil {
+		c.depth = max(*options.Depth, 0)
+	}
+
+	if c.maxPages <= 0 {
+		c.maxPages = DefaultMaxPages
+	}
+
+	if c.maxSize <= 0 {
+		c.maxSize = DefaultMaxSize
+	}
+
+	return c
+}
+
+type target struct {
+	url   *url.URL
+	depth int
+}
+
+// Crawl visits start and the pages it links to on the same host, breadth
+// first, honoring robots.txt. If start is a sitemap, the pages it lists are
+// crawled instead. Pages that fail to load are passed to errFn if set.
+func (c *Crawler) Crawl(ctx context.Context, start string, visit VisitFn, errFn func(url string, err error)) error {
+	u, err := url.Parse(start)
+
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("unsupported url: " + start)
+	}
+
+	u.Fragment = ""
+
+	prefix := c.prefix
+
+	if prefix == "" {
+		prefix = dir(u.Path)
+	}
+
+	robots := c.robots(ctx, u)
+
+	queue := []target{{url: u}}
+	seen := map[string]bool{u.String(): true}
+
+	pages := 0
+	first := true
+
+	for len(queue) > 0 && pages < c.maxPages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		t := queue[0]
+		queue = queue[1:]
+
+		if !robots.Allowed(t.url.RequestURI()) {
+			continue
+		}
+
+		if robots.Delay > 0 && pages > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(robots.Delay):
+			}
+		}
+
+		page, err := c.fetch(ctx, t.url)
+
+		if err != nil {
+			if errFn != nil {
+				errFn(t.url.String(), err)
+			}
+
+			continue
+		}
+
+		// follow redirects only on the same host and to unseen pages
+		if page.URL != t.url.String() {
+			r, err := url.Parse(page.URL)
+
+			if err != nil {
+				continue
+			}
+
+			// a start page moved to another scheme or host, e.g. from http
+			// to https, is crawled where it moved to
+			if first && (r.Scheme != u.Scheme || r.Host != u.Host) {
+				u = r
+
+				if c.prefix == "" {
+					prefix = dir(u.Path)
+				}
+
+				robots = c.robots(ctx, u)
+			}
+
+			if r.Scheme != u.Scheme || r.Host != u.Host || seen[page.URL] {
+				continue
+			}
+
+			seen[page.URL] = true
+		}
+
+		first = false
+
+		var links []*url.URL
+
+		if urls, ok := sitemapURLs(page); ok {
+			// the pages listed by a sitemap are crawled as if linked from
+			// the start page
+			for _, s := range urls {
+				if l, err := t.url.Parse(s); err == nil {
+					links = append(links, l)
+				}
+			}
+
+			t.depth--
+		} else {
+			pages++
+
+			if err := visit(ctx, *page); err != nil {
+				return err
+			}
+
+			if t.depth < c.depth {
+				links = pageLinks(page)
+			}
+		}
+
+		for _, l := range links {
+			l.Fragment = ""
+
+			if l.Scheme != u.Scheme || l.Host != u.Host || !strings.HasPrefix(l.Path, prefix) {
+				continue
+			}
+
+			if seen[l.String()] {
+				continue
+			}
+
+			seen[l.String()] = true
+			queue = append(queue, target{url: l, depth: t.depth + 1})
+		}
+	}
+
+	return nil
+}
+
+func (c *Crawler) fetch(ctx context.Context, u *url.URL) (*Page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, c.maxSize+1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > c.maxSize {
+		return nil, fmt.Errorf("page exceeds %d bytes", c.maxSize)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	// redirects may have moved the page
+	return &Page{
+		URL: resp.Request.URL.String(),
+
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
+
+// IsHTML reports whether the page is an html document.
+func (p *Page) IsHTML() bool {
+	mediaType, _, _ := mime.ParseMediaType(p.ContentType)
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+func pageLinks(page *Page) []*url.URL {
+	if !page.IsHTML() {
+		return nil
+	}
+
+	doc, err := html.Parse(bytes.NewReader(page.Data))
+
+	if err != nil {
+		return nil
+	}
+
+	base, err := url.Parse(page.URL)
+
+	if err != nil {
+		return nil
+	}
+
+	var links []*url.URL
+	var nofollow bool
+
+	var walk func(n *html.Node)
+
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			for _, a := range n.Attr {
+				switch {
+				case n.Data == "base" && a.Key == "href":
+					if u, err := base.Parse(a.Val); err == nil {
+						base = u
+					}
+
+				case n.Data == "a" && a.Key == "href":
+					if u, err := base.Parse(strings.TrimSpace(a.Val)); err == nil {
+						links = append(links, u)
+					}
+
+				case n.Data == "meta" && a.Key == "content" && strings.Contains(strings.ToLower(a.Val), "nofollow"):
+					nofollow = nofollow || isRobotsMeta(n)
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	walk(doc)
+
+	if nofollow {
+		return nil
+	}
+
+	return links
+}
+
+func isRobotsMeta(n *html.Node) bool {
+	for _, a := range n.Attr {
+		if a.Key == "name" && strings.EqualFold(a.Val, "robots") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dir returns the directory of an url path, including the trailing slash.
+func dir(path string) string {
+	return path[:strings.LastIndex(path, "/")+1]
+}
@@ -0,0 +1,392 @@
+package crawl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// site serves html pages with the given links; pages without an entry are
+// not found.
+type site struct {
+	pages map[string][]string
+	files map[string]string
+
+	redirects map[string]string
+}
+
+func (s *site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if target, ok := s.redirects[r.URL.Path]; ok {
+		http.Redirect(w, r, target, http.StatusFound)
+		return
+	}
+
+	if body, ok := s.files[r.URL.Path]; ok {
+		if strings.HasSuffix(r.URL.Path, ".gz") {
+			w.Header().Set("Content-Type", "application/gzip")
+		} else {
+			w.Header().Set("Content-Type", "application/xml")
+		}
+
+		fmt.Fprint(w, body)
+		return
+	}
+
+	links, ok := s.pages[r.URL.Path]
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+
+	fmt.Fprint(w, "<html><body>")
+
+	for _, l := range links {
+		fmt.Fprintf(w, `<a href="%s">link</a>`, l)
+	}
+
+	fmt.Fprint(w, "</body></html>")
+}
+
+func crawl(t *testing.T, start string, options *Options) []string {
+	t.Helper()
+
+	var visited []string
+
+	visit := func(ctx context.Context, page Page) error {
+		u, err := url.Parse(page.URL)
+
+		if err != nil {
+			return err
+		}
+
+		visited = append(visited, u.Path)
+		return nil
+	}
+
+	if err := New(options).Crawl(context.Background(), start, visit, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(visited)
+
+	return visited
+}
+
+func TestCrawl(t *testing.T) {
+	other := httptest.NewServer(&site{
+		pages: map[string][]string{
+			"/docs/elsewhere.html": nil,
+		},
+	})
+
+	defer other.Close()
+
+	s := &site{
+		pages: map[string][]string{
+			"/docs/index.html": {
+				"a.html",
+				"/docs/b.html#section",
+				"/blog/post.html",
+				other.URL + "/docs/elsewhere.html",
+				"/docs/private/secret.html",
+				"/docs/private/public.html",
+				"/docs/moved.html",
+				"/docs/missing.html",
+			},
+
+			"/docs/a.html":              {"c.html", "index.html"},
+			"/docs/b.html":              nil,
+			"/docs/c.html":              {"d.html"},
+			"/docs/d.html":              nil,
+			"/docs/private/secret.html": nil,
+			"/docs/private/public.html": nil,
+			"/blog/post.html":           nil,
+		},
+
+		files: map[string]string{
+			"/robots.txt": "User-agent: *\nDisallow: /docs/private/\nAllow: /docs/private/public.html\n",
+		},
+
+		redirects: map[string]string{
+			"/docs/moved.html": other.URL + "/docs/elsewhere.html",
+		},
+	}
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	tests := []struct {
+		name string
+
+		start   string
+		options Options
+
+		want []string
+	}{
+		{
+			name:  "start page only",
+			start: "/docs/index.html",
+
+			options: Options{Depth: intPtr(0)},
+
+			want: []string{"/docs/index.html"},
+		},
+		{
+			name:  "links of the start page",
+			start: "/docs/index.html",
+
+			options: Options{Depth: intPtr(1)},
+
+			want: []string{"/docs/a.html", "/docs/b.html", "/docs/index.html", "/docs/private/public.html"},
+		},
+		{
+			name:  "two levels",
+			start: "/docs/index.html",
+
+			options: Options{Depth: intPtr(2)},
+
+			want: []string{"/docs/a.html", "/docs/b.html", "/docs/c.html", "/docs/index.html", "/docs/private/public.html"},
+		},
+		{
+			name:  "prefix",
+			start: "/docs/index.html",
+
+			options: Options{Depth: intPtr(1), Prefix: "/"},
+
+			want: []string{"/blog/post.html", "/docs/a.html", "/docs/b.html", "/docs/index.html", "/docs/private/public.html"},
+		},
+		{
+			name:  "max pages",
+			start: "/docs/index.html",
+
+			options: Options{Depth: intPtr(1), MaxPages: 2},
+
+			want: []string{"/docs/a.html", "/docs/index.html"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := crawl(t, server.URL+test.start, &test.options)
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("visited %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCrawlRedirect(t *testing.T) {
+	other := httptest.NewServer(&site{
+		pages: map[string][]string{
+			"/page.html": nil,
+		},
+	})
+
+	defer other.Close()
+
+	server := httptest.NewServer(&site{
+		pages: map[string][]string{
+			"/index.html": {"/old.html", "/moved.html"},
+			"/new.html":   nil,
+		},
+
+		redirects: map[string]string{
+			"/old.html":   "/new.html",
+			"/moved.html": other.URL + "/page.html",
+		},
+	})
+
+	defer server.Close()
+
+	got := crawl(t, server.URL+"/index.html", nil)
+	want := []string{"/index.html", "/new.html"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("visited %v, want %v", got, want)
+	}
+}
+
+func TestCrawlRedirectStart(t *testing.T) {
+	secure := httptest.NewTLSServer(&site{
+		pages: map[string][]string{
+			"/docs/index.html":   {"a.html", "b.html", "private.html"},
+			"/docs/a.html":       nil,
+			"/docs/b.html":       nil,
+			"/docs/private.html": nil,
+		},
+
+		files: map[string]string{
+			"/robots.txt": "User-agent: *\nDisallow: /docs/private.html\n",
+		},
+	})
+
+	defer secure.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nDisallow: /docs/b.html\n")
+			return
+		}
+
+		http.Redirect(w, r, secure.URL+r.URL.RequestURI(), http.StatusMovedPermanently)
+	}))
+
+	defer server.Close()
+
+	var visited []string
+
+	visit := func(ctx context.Context, page Page) error {
+		visited = append(visited, page.URL)
+		return nil
+	}
+
+	crawler := New(&Options{
+		Client: secure.Client(),
+	})
+
+	if err := crawler.Crawl(context.Background(), server.URL+"/docs/index.html", visit, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(visited)
+
+	want := []string{secure.URL + "/docs/a.html", secure.URL + "/docs/b.html", secure.URL + "/docs/index.html"}
+
+	if !slices.Equal(visited, want) {
+		t.Errorf("visited %v, want %v", visited, want)
+	}
+}
+
+func TestCrawlDelay(t *testing.T) {
+	server := httptest.NewServer(&site{
+		pages: map[string][]string{
+			"/index.html": {"a.html", "b.html"},
+			"/a.html":     nil,
+			"/b.html":     nil,
+		},
+
+		files: map[string]string{
+			"/robots.txt": "User-agent: *\nCrawl-delay: 0.1\n",
+		},
+	})
+
+	defer server.Close()
+
+	started := time.Now()
+
+	got := crawl(t, server.URL+"/index.html", nil)
+
+	if len(got) != 3 {
+		t.Fatalf("visited %v, want 3 pages", got)
+	}
+
+	// no delay before the first page
+	if elapsed := time.Since(started); elapsed < 200*time.Millisecond {
+		t.Errorf("crawled in %s, want at least 200ms", elapsed)
+	}
+}
+
+func TestCrawlSitemap(t *testing.T) {
+	var compressed bytes.Buffer
+
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>/c.html</loc></url>
+</urlset>`))
+	gz.Close()
+
+	s := &site{
+		pages: map[string][]string{
+			"/a.html": {"linked.html"},
+			"/b.html": nil,
+			"/c.html": nil,
+
+			"/private.html": nil,
+			"/linked.html":  nil,
+		},
+
+		files: map[string]string{
+			"/robots.txt": "User-agent: *\nDisallow: /private.html\n",
+
+			"/sitemap_index.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>/sitemap.xml</loc></sitemap>
+	<sitemap><loc>/sitemap.xml.gz</loc></sitemap>
+</sitemapindex>`,
+
+			"/sitemap.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>/a.html</loc></url>
+	<url><loc> /b.html </loc></url>
+	<url><loc>/private.html</loc></url>
+	<url><loc>/missing.html</loc></url>
+</urlset>`,
+
+			"/sitemap.xml.gz": compressed.String(),
+		},
+	}
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	tests := []struct {
+		name string
+
+		start   string
+		options Options
+
+		want []string
+	}{
+		{
+			name:  "sitemap",
+			start: "/sitemap.xml",
+
+			options: Options{Depth: intPtr(0)},
+
+			want: []string{"/a.html", "/b.html"},
+		},
+		{
+			name:  "sitemap links",
+			start: "/sitemap.xml",
+
+			options: Options{Depth: intPtr(1)},
+
+			want: []string{"/a.html", "/b.html", "/linked.html"},
+		},
+		{
+			name:  "sitemap index",
+			start: "/sitemap_index.xml",
+
+			options: Options{Depth: intPtr(0)},
+
+			want: []string{"/a.html", "/b.html", "/c.html"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := crawl(t, server.URL+test.start, &test.options)
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("visited %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,187 @@
+package crawl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxDelay = 10 * time.Second
+
+// Robots holds the rules of a robots.txt that apply to this crawler.
+type Robots struct {
+	rules []rule
+
+	// Delay between requests as asked for by the site
+	Delay time.Duration
+}
+
+type rule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// robots loads the robots.txt of the host of u. Sites without one, or
+// whose robots.txt cannot be loaded, allow everything.
+func (c *Crawler) robots(ctx context.Context, u *url.URL) *Robots {
+	robots := &url.URL{
+		Scheme: u.Scheme,
+		Host:   u.Host,
+		Path:   "/robots.txt",
+	}
+
+	page, err := c.fetch(ctx, robots)
+
+	if err != nil {
+		return &Robots{}
+	}
+
+	return ParseRobots(page.Data, UserAgent)
+}
+
+// ParseRobots parses the groups of a robots.txt for agent, falling back to
+// the rules for all agents ("*").
+func ParseRobots(data []byte, agent string) *Robots {
+	type group struct {
+		agents []string
+
+		rules []rule
+		delay time.Duration
+	}
+
+	var groups []*group
+	var current *group
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+
+		if !ok {
+			continue
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// consecutive user-agent lines share a group
+			if current == nil || len(current.rules) > 0 || current.delay > 0 {
+				current = &group{}
+				groups = append(groups, current)
+			}
+
+			current.agents = append(current.agents, strings.ToLower(value))
+
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+
+			// an empty disallow allows everything
+			if value == "" {
+				continue
+			}
+
+			current.rules = append(current.rules, rule{
+				allow:   key == "allow",
+				pattern: value,
+				re:      robotsPattern(value),
+			})
+
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.delay = min(time.Duration(seconds*float64(time.Second)), maxDelay)
+			}
+		}
+	}
+
+	agent = strings.ToLower(agent)
+
+	var matched, fallback []*group
+
+	for _, g := range groups {
+		for _, a := range g.agents {
+			if a == "*" {
+				fallback = append(fallback, g)
+			} else if strings.Contains(agent, a) {
+				matched = append(matched, g)
+			}
+		}
+	}
+
+	if len(matched) == 0 {
+		matched = fallback
+	}
+
+	robots := &Robots{}
+
+	for _, g := range matched {
+		robots.rules = append(robots.rules, g.rules...)
+		robots.Delay = max(robots.Delay, g.delay)
+	}
+
+	return robots
+}
+
+// Allowed reports whether path (including the query) may be crawled. The
+// most specific, i.e. longest, matching rule wins, allow rules win ties.
+func (r *Robots) Allowed(path string) bool {
+	var best *rule
+
+	for i := range r.rules {
+		rule := &r.rules[i]
+
+		if !rule.re.MatchString(path) {
+			continue
+		}
+
+		if best == nil || len(rule.pattern) > len(best.pattern) || (len(rule.pattern) == len(best.pattern) && rule.allow) {
+			best = rule
+		}
+	}
+
+	return best == nil || best.allow
+}
+
+// robotsPattern translates a robots.txt path pattern, which may contain "*"
+// wildcards and end with "$", to a regular expression.
+func robotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	var expr strings.Builder
+
+	expr.WriteString("^")
+
+	for i, part := range strings.Split(pattern, "*") {
+		if i > 0 {
+			expr.WriteString(".*")
+		}
+
+		expr.WriteString(regexp.QuoteMeta(part))
+	}
+
+	if anchored {
+		expr.WriteString("$")
+	}
+
+	return regexp.MustCompile(expr.String())
+}
@@ -0,0 +1,174 @@
+package crawl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRobotsAllowed(t *testing.T) {
+	tests := []struct {
+		name string
+
+		robots string
+		agent  string
+
+		path string
+		want bool
+	}{
+		{
+			name:   "empty",
+			robots: "",
+			path:   "/page",
+			want:   true,
+		},
+		{
+			name:   "disallow prefix",
+			robots: "User-agent: *\nDisallow: /private\n",
+			path:   "/private/page",
+			want:   false,
+		},
+		{
+			name:   "other path",
+			robots: "User-agent: *\nDisallow: /private\n",
+			path:   "/public/page",
+			want:   true,
+		},
+		{
+			name:   "empty disallow",
+			robots: "User-agent: *\nDisallow:\n",
+			path:   "/page",
+			want:   true,
+		},
+		{
+			name:   "longer allow wins",
+			robots: "User-agent: *\nDisallow: /docs/\nAllow: /docs/public/\n",
+			path:   "/docs/public/page",
+			want:   true,
+		},
+		{
+			name:   "longer disallow wins",
+			robots: "User-agent: *\nAllow: /docs/\nDisallow: /docs/private/\n",
+			path:   "/docs/private/page",
+			want:   false,
+		},
+		{
+			name:   "allow wins ties",
+			robots: "User-agent: *\nDisallow: /page\nAllow: /page\n",
+			path:   "/page",
+			want:   true,
+		},
+		{
+			name:   "wildcard",
+			robots: "User-agent: *\nDisallow: /*.pdf\n",
+			path:   "/files/report.pdf",
+			want:   false,
+		},
+		{
+			name:   "end anchor",
+			robots: "User-agent: *\nDisallow: /*.pdf$\n",
+			path:   "/files/report.pdf?download=1",
+			want:   true,
+		},
+		{
+			name:   "query",
+			robots: "User-agent: *\nDisallow: /search?\n",
+			path:   "/search?q=test",
+			want:   false,
+		},
+		{
+			name:   "comments",
+			robots: "# rules\nUser-agent: * # everyone\nDisallow: /private # hidden\n",
+			path:   "/private",
+			want:   false,
+		},
+		{
+			name:   "agent group",
+			robots: "User-agent: *\nDisallow: /\n\nUser-agent: Wingman\nDisallow: /private\n",
+			agent:  "wingman",
+			path:   "/page",
+			want:   true,
+		},
+		{
+			name:   "other agent",
+			robots: "User-agent: otherbot\nDisallow: /\n",
+			agent:  "wingman",
+			path:   "/page",
+			want:   true,
+		},
+		{
+			name:   "shared group",
+			robots: "User-agent: otherbot\nUser-agent: wingman\nDisallow: /private\n",
+			agent:  "wingman",
+			path:   "/private",
+			want:   false,
+		},
+		{
+			name:   "rules before agent",
+			robots: "Disallow: /\nUser-agent: *\nDisallow: /private\n",
+			path:   "/page",
+			want:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			agent := test.agent
+
+			if agent == "" {
+				agent = UserAgent
+			}
+
+			if got := ParseRobots([]byte(test.robots), agent).Allowed(test.path); got != test.want {
+				t.Errorf("Allowed(%q) = %v, want %v", test.path, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRobotsDelay(t *testing.T) {
+	tests := []struct {
+		name string
+
+		robots string
+		want   time.Duration
+	}{
+		{
+			name:   "none",
+			robots: "User-agent: *\nDisallow: /private\n",
+			want:   0,
+		},
+		{
+			name:   "seconds",
+			robots: "User-agent: *\nCrawl-delay: 2\n",
+			want:   2 * time.Second,
+		},
+		{
+			name:   "fraction",
+			robots: "User-agent: *\nCrawl-delay: 0.5\n",
+			want:   500 * time.Millisecond,
+		},
+		{
+			name:   "capped",
+			robots: "User-agent: *\nCrawl-delay: 3600\n",
+			want:   maxDelay,
+		},
+		{
+			name:   "invalid",
+			robots: "User-agent: *\nCrawl-delay: soon\n",
+			want:   0,
+		},
+		{
+			name:   "other agent",
+			robots: "User-agent: otherbot\nCrawl-delay: 5\n",
+			want:   0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ParseRobots([]byte(test.robots), UserAgent).Delay; got != test.want {
+				t.Errorf("Delay = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package crawl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// sitemapURLs returns the locations listed by a sitemap or sitemap index.
+// It reports false if the page is not a sitemap.
+func sitemapURLs(page *Page) ([]string, bool) {
+	data := page.Data
+
+	// gzip compressed sitemaps, e.g. sitemap.xml.gz
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+
+		if err != nil {
+			return nil, false
+		}
+
+		if data, err = io.ReadAll(io.LimitReader(r, DefaultMaxSize)); err != nil {
+			return nil, false
+		}
+	}
+
+	if page.IsHTML() || !bytes.Contains(data, []byte("<urlset")) && !bytes.Contains(data, []byte("<sitemapindex")) {
+		return nil, false
+	}
+
+	var sitemap struct {
+		XMLName xml.Name
+
+		URLs []struct {
+			Loc string `xml:"loc"`
+		} `xml:"url"`
+
+		Sitemaps []struct {
+			Loc string `xml:"loc"`
+		} `xml:"sitemap"`
+	}
+
+	if err := xml.Unmarshal(data, &sitemap); err != nil {
+		return nil, false
+	}
+
+	if sitemap.XMLName.Local != "urlset" && sitemap.XMLName.Local != "sitemapindex" {
+		return nil, false
+	}
+
+	var urls []string
+
+	for _, u := range sitemap.URLs {
+		if loc := strings.TrimSpace(u.Loc); loc != "" {
+			urls = append(urls, loc)
+		}
+	}
+
+	for _, s := range sitemap.Sitemaps {
+		if loc := strings.TrimSpace(s.Loc); loc != "" {
+			urls = append(urls, loc)
+		}
+	}
+
+	return urls, true
+}
@@ -0,0 +1,56 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// csvText renders each record on a line with its fields separated by " | ",
+// which keeps rows intact when chunked at line boundaries.
+func csvText(comma rune) func(data []byte) (string, error) {
+	return func(data []byte) (string, error) {
+		text, err := plainText(data)
+
+		if err != nil {
+			return "", err
+		}
+
+		r := csv.NewReader(strings.NewReader(text))
+		r.Comma = comma
+		r.FieldsPerRecord = -1
+		r.LazyQuotes = true
+
+		var buf bytes.Buffer
+
+		for {
+			record, err := r.Read()
+
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			if err != nil {
+				return "", err
+			}
+
+			writeRow(&buf, record)
+		}
+
+		return buf.String(), nil
+	}
+}
+
+func writeRow(buf *bytes.Buffer, fields []string) {
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteString(" | ")
+		}
+
+		buf.WriteString(strings.Join(strings.Fields(f), " "))
+	}
+
+	buf.WriteString("\n")
+}
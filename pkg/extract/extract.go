@@ -0,0 +1,68 @@
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrUnsupported = errors.New("unsupported format")
+
+var formats = map[string]func(data []byte) (string, error){
+	".txt":  plainText,
+	".text": plainText,
+	".log":  plainText,
+	".md":   plainText,
+	".rst":  plainText,
+
+	".csv": csvText(','),
+	".tsv": csvText('\t'),
+
+	".htm":  htmlText,
+	".html": htmlText,
+
+	".docx": docxText,
+	".pptx": pptxText,
+	".xlsx": xlsxText,
+}
+
+// Supported reports whether files named name can be extracted locally.
+func Supported(name string) bool {
+	_, ok := formats[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// Plain reports whether the text extracted from files named name is their
+// content as is, so that its lines are the lines of the file.
+func Plain(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".txt", ".text", ".log", ".md", ".rst":
+		return true
+	}
+
+	return false
+}
+
+// Text extracts the text of a file by its extension. It returns
+// ErrUnsupported for formats that cannot be handled locally.
+func Text(name string, data []byte) (string, error) {
+	fn, ok := formats[strings.ToLower(filepath.Ext(name))]
+
+	if !ok {
+		return "", ErrUnsupported
+	}
+
+	return fn(data)
+}
+
+func plainText(data []byte) (string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	if !utf8.Valid(data) {
+		return strings.ToValidUTF8(string(data), "�"), nil
+	}
+
+	return string(data), nil
+}
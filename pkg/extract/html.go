@@ -0,0 +1,180 @@
+package extract
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func htmlText(data []byte) (string, error) {
+	return HTML(bytes.NewReader(data))
+}
+
+// HTML converts an html document to text, keeping headings, paragraphs,
+// list items and table rows on lines of their own. Scripts, styles and
+// navigation are left out.
+func HTML(r io.Reader) (string, error) {
+	doc, err := html.Parse(r)
+
+	if err != nil {
+		return "", err
+	}
+
+	w := &textWriter{}
+
+	var walk func(n *html.Node, pre bool)
+
+	walk = func(n *html.Node, pre bool) {
+		switch n.Type {
+		case html.TextNode:
+			if pre {
+				w.raw(n.Data)
+				return
+			}
+
+			if strings.TrimLeft(n.Data, " \t\r\n") != n.Data {
+				w.space()
+			}
+
+			if text := strings.Join(strings.Fields(n.Data), " "); text != "" {
+				w.text(text)
+			}
+
+			if strings.TrimRight(n.Data, " \t\r\n") != n.Data {
+				w.space()
+			}
+
+			return
+
+		case html.ElementNode:
+			if isBlock(n.DataAtom) {
+				w.newline(2)
+			}
+
+			switch n.DataAtom {
+			case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Svg, atom.Head, atom.Nav, atom.Iframe:
+				return
+
+			case atom.Br:
+				w.newline(1)
+				return
+
+			case atom.Pre:
+				pre = true
+
+			case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				w.text(strings.Repeat("#", int(n.Data[1]-'0')))
+				w.space()
+
+			case atom.Li:
+				w.newline(1)
+				w.text("-")
+				w.space()
+
+			case atom.Tr:
+				w.newline(1)
+
+			case atom.Td, atom.Th:
+				if previousElement(n) != nil {
+					w.space()
+					w.text("|")
+					w.space()
+				}
+			}
+
+		case html.CommentNode, html.DoctypeNode:
+			return
+		}
+
+		block := n.Type == html.ElementNode && isBlock(n.DataAtom)
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, pre)
+		}
+
+		if block {
+			w.newline(2)
+		}
+	}
+
+	walk(doc, false)
+
+	return w.String(), nil
+}
+
+func isBlock(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer, atom.Aside,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+		atom.Ul, atom.Ol, atom.Dl, atom.Dt, atom.Dd,
+		atom.Table, atom.Blockquote, atom.Pre, atom.Figure, atom.Figcaption,
+		atom.Hr, atom.Form, atom.Fieldset, atom.Details, atom.Summary:
+		return true
+	}
+
+	return false
+}
+
+func previousElement(n *html.Node) *html.Node {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// textWriter collapses whitespace between pieces of text, so that text is
+// separated by at most the requested line breaks or a single space.
+type textWriter struct {
+	buf strings.Builder
+
+	newlines int
+	spaced   bool
+}
+
+func (w *textWriter) text(s string) {
+	if w.buf.Len() > 0 {
+		if w.newlines > 0 {
+			w.buf.WriteString(strings.Repeat("\n", w.newlines))
+		} else if w.spaced {
+			w.buf.WriteString(" ")
+		}
+	}
+
+	w.buf.WriteString(s)
+
+	w.newlines = 0
+	w.spaced = false
+}
+
+func (w *textWriter) raw(s string) {
+	if s == "" {
+		return
+	}
+
+	w.text("")
+	w.buf.WriteString(s)
+}
+
+func (w *textWriter) newline(n int) {
+	w.newlines = max(w.newlines, n)
+}
+
+func (w *textWriter) space() {
+	w.spaced = true
+}
+
+func (w *textWriter) String() string {
+	var lines []string
+
+	for _, line := range strings.Split(w.buf.String(), "\n") {
+		lines = append(lines, strings.TrimRight(line, " \t\r"))
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n")) + "\n"
+}
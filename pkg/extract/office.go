@@ -0,0 +1,357 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// docxText extracts the paragraphs of a word document, one per line.
+func docxText(data []byte) (string, error) {
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		return "", err
+	}
+
+	f, err := z.Open("word/document.xml")
+
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	var buf bytes.Buffer
+
+	// depth of table cells, whose paragraphs stay on the line of their row
+	var cells int
+
+	d := xml.NewDecoder(f)
+
+	for {
+		token, err := d.Token()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				var text string
+
+				if err := d.DecodeElement(&text, &t); err != nil {
+					return "", err
+				}
+
+				buf.WriteString(text)
+
+			case "tab":
+				buf.WriteString("\t")
+
+			case "br", "cr":
+				buf.WriteString("\n")
+
+			case "tc":
+				cells++
+			}
+
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p":
+				if cells > 0 {
+					buf.WriteString(" ")
+				} else {
+					buf.WriteString("\n\n")
+				}
+
+			case "tc":
+				cells--
+				buf.WriteString("| ")
+
+			case "tr":
+				buf.WriteString("\n")
+
+			case "tbl":
+				buf.WriteString("\n")
+			}
+		}
+	}
+
+	return strings.TrimSpace(collapseBlankLines(buf.String())) + "\n", nil
+}
+
+// xlsxText extracts the cell values of all sheets of a workbook, with each
+// sheet under a heading and each row on a line.
+func xlsxText(data []byte) (string, error) {
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		return "", err
+	}
+
+	shared, err := sharedStrings(z)
+
+	if err != nil {
+		return "", err
+	}
+
+	sheets, err := workbookSheets(z)
+
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+
+	for _, s := range sheets {
+		f, err := z.Open(s.path)
+
+		if err != nil {
+			return "", err
+		}
+
+		rows, err := sheetRows(f, shared)
+		f.Close()
+
+		if err != nil {
+			return "", err
+		}
+
+		if len(rows) == 0 {
+			continue
+		}
+
+		buf.WriteString("## " + s.name + "\n\n")
+
+		for _, row := range rows {
+			writeRow(&buf, row)
+		}
+
+		buf.WriteString("\n")
+	}
+
+	return buf.String(), nil
+}
+
+func sharedStrings(z *zip.Reader) ([]string, error) {
+	f, err := z.Open("xl/sharedStrings.xml")
+
+	if err != nil {
+		// workbooks without text cells have no shared strings
+		return nil, nil
+	}
+
+	defer f.Close()
+
+	var sst struct {
+		Items []struct {
+			Text string `xml:"t"`
+
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+
+	if err := xml.NewDecoder(f).Decode(&sst); err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for _, si := range sst.Items {
+		text := si.Text
+
+		for _, r := range si.Runs {
+			text += r.Text
+		}
+
+		result = append(result, text)
+	}
+
+	return result, nil
+}
+
+type sheet struct {
+	name string
+	path string
+}
+
+// workbookSheets returns the sheets in the order of the workbook, resolving
+// their parts through the workbook relationships.
+func workbookSheets(z *zip.Reader) ([]sheet, error) {
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+
+			// r:id in the relationships namespace
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	if err := decodeFile(z, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+
+	if err := decodeFile(z, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]string)
+
+	for _, r := range rels.Relationships {
+		target := r.Target
+
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+
+		targets[r.ID] = target
+	}
+
+	var result []sheet
+
+	for _, s := range workbook.Sheets {
+		target, ok := targets[s.ID]
+
+		if !ok {
+			continue
+		}
+
+		result = append(result, sheet{
+			name: s.Name,
+			path: target,
+		})
+	}
+
+	return result, nil
+}
+
+func sheetRows(r io.Reader, shared []string) ([][]string, error) {
+	var worksheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref   string `xml:"r,attr"`
+				Type  string `xml:"t,attr"`
+				Value string `xml:"v"`
+
+				Inline struct {
+					Text string `xml:"t"`
+				} `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+
+	if err := xml.NewDecoder(r).Decode(&worksheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+
+	for _, row := range worksheet.Rows {
+		var values []string
+
+		for _, c := range row.Cells {
+			value := c.Value
+
+			switch c.Type {
+			case "s":
+				if n, err := strconv.Atoi(c.Value); err == nil && n >= 0 && n < len(shared) {
+					value = shared[n]
+				}
+
+			case "inlineStr":
+				value = c.Inline.Text
+			}
+
+			// place values in their column, as empty cells are omitted
+			if col := columnIndex(c.Ref); col >= len(values) {
+				values = append(values, make([]string, col-len(values))...)
+			}
+
+			values = append(values, value)
+		}
+
+		if strings.TrimSpace(strings.Join(values, "")) == "" {
+			continue
+		}
+
+		rows = append(rows, values)
+	}
+
+	return rows, nil
+}
+
+// columnIndex returns the zero-based column of a cell reference like "C7".
+func columnIndex(ref string) int {
+	col := 0
+
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+
+		col = col*26 + int(r-'A'+1)
+	}
+
+	return col - 1
+}
+
+func decodeFile(z *zip.Reader, name string, v any) error {
+	f, err := z.Open(name)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	return xml.NewDecoder(f).Decode(v)
+}
+
+func collapseBlankLines(s string) string {
+	lines := strings.Split(s, "\n")
+
+	var result []string
+
+	blank := 0
+
+	for _, line := range lines {
+		line = strings.TrimRight(line, " |\t")
+
+		if strings.TrimSpace(line) == "" {
+			blank++
+
+			if blank > 1 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+
+		result = append(result, line)
+	}
+
+	return strings.Join(result, "\n")
+}
@@ -0,0 +1,143 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// PageBreak separates pages or slides in extracted text, as done by common
+// pdf to text converters.
+const PageBreak = "\f"
+
+// pptxText extracts the text of each slide of a presentation, in the order
+// of the presentation and separated by PageBreak.
+func pptxText(data []byte) (string, error) {
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		return "", err
+	}
+
+	slides, err := presentationSlides(z)
+
+	if err != nil {
+		return "", err
+	}
+
+	var texts []string
+
+	for _, name := range slides {
+		f, err := z.Open(name)
+
+		if err != nil {
+			return "", err
+		}
+
+		text, err := slideText(f)
+		f.Close()
+
+		if err != nil {
+			return "", err
+		}
+
+		texts = append(texts, text)
+	}
+
+	return strings.Join(texts, "\n"+PageBreak), nil
+}
+
+func presentationSlides(z *zip.Reader) ([]string, error) {
+	var presentation struct {
+		Slides []struct {
+			// r:id in the relationships namespace
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sldIdLst>sldId"`
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	if err := decodeFile(z, "ppt/presentation.xml", &presentation); err != nil {
+		return nil, err
+	}
+
+	if err := decodeFile(z, "ppt/_rels/presentation.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]string)
+
+	for _, r := range rels.Relationships {
+		target := r.Target
+
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("ppt", target)
+		}
+
+		targets[r.ID] = target
+	}
+
+	var result []string
+
+	for _, s := range presentation.Slides {
+		if target, ok := targets[s.ID]; ok {
+			result = append(result, target)
+		}
+	}
+
+	return result, nil
+}
+
+// slideText returns the paragraphs of a slide, one per line.
+func slideText(r io.Reader) (string, error) {
+	var buf bytes.Buffer
+
+	d := xml.NewDecoder(r)
+
+	for {
+		token, err := d.Token()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				var text string
+
+				if err := d.DecodeElement(&text, &t); err != nil {
+					return "", err
+				}
+
+				buf.WriteString(text)
+
+			case "br":
+				buf.WriteString("\n")
+			}
+
+		case xml.EndElement:
+			if t.Name.Local == "p" {
+				buf.WriteString("\n")
+			}
+		}
+	}
+
+	return strings.TrimSpace(collapseBlankLines(buf.String())) + "\n", nil
+}
@@ -0,0 +1,201 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Commit struct {
+	Hash string
+
+	Author string
+	Email  string
+	Date   time.Time
+
+	Subject string
+	Body    string
+}
+
+type LogOptions struct {
+	// Range of commits, e.g. "v1.0..HEAD"; defaults to HEAD
+	Range string
+
+	// Since limits the log to commits more recent than a date, e.g.
+	// "2024-01-01" or "6 months ago"
+	Since string
+
+	// MaxCommits limits the log to the most recent commits
+	MaxCommits int
+
+	// Paths limits the log to commits touching these paths
+	Paths []string
+}
+
+// Log lists the commits of the repository containing dir, newest first.
+func Log(ctx context.Context, dir string, options *LogOptions) ([]Commit, error) {
+	if options == nil {
+		options = new(LogOptions)
+	}
+
+	// fields are separated by unit separators, commits by record separators
+	args := []string{"log", "--no-color", "--format=%H%x1f%an%x1f%ae%x1f%aI%x1f%B%x1e"}
+
+	if options.Since != "" {
+		args = append(args, "--since="+options.Since)
+	}
+
+	if options.MaxCommits > 0 {
+		args = append(args, "--max-count="+strconv.Itoa(options.MaxCommits))
+	}
+
+	rev := options.Range
+
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	// the range comes from config files of the repository, it must not be
+	// taken for an option such as --output
+	if strings.HasPrefix(rev, "-") {
+		return nil, errors.New("invalid range: " + rev)
+	}
+
+	args = append(args, rev, "--")
+	args = append(args, options.Paths...)
+
+	output, err := run(ctx, dir, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+
+	for _, record := range strings.Split(string(output), "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+
+		if record == "" {
+			continue
+		}
+
+		fields := strings.SplitN(record, "\x1f", 5)
+
+		if len(fields) != 5 {
+			return nil, errors.New("unexpected git log output")
+		}
+
+		date, _ := time.Parse(time.RFC3339, fields[3])
+
+		subject, body, _ := strings.Cut(strings.TrimSpace(fields[4]), "\n")
+
+		commits = append(commits, Commit{
+			Hash: fields[0],
+
+			Author: fields[1],
+			Email:  fields[2],
+			Date:   date,
+
+			Subject: strings.TrimSpace(subject),
+			Body:    strings.TrimSpace(body),
+		})
+	}
+
+	return commits, nil
+}
+
+// Files lists the paths changed by a commit.
+func Files(ctx context.Context, dir, hash string) ([]string, error) {
+	output, err := run(ctx, dir, "diff-tree", "--no-commit-id", "--name-only", "-r", "-z", "--root", hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+
+	for _, name := range strings.Split(string(output), "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+
+	return files, nil
+}
+
+// Diff returns the patch of a commit, cut at a line boundary once it exceeds
+// maxSize bytes. It reports whether the patch was truncated.
+func Diff(ctx context.Context, dir, hash string, maxSize int) (string, bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "show", "--no-color", "--no-ext-diff", "--format=", "--patch", hash)
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+
+	if err != nil {
+		return "", false, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return "", false, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(stdout, int64(maxSize)+1))
+
+	truncated := len(data) > maxSize
+
+	if truncated {
+		// the rest of the output is not needed
+		cancel()
+
+		data = data[:maxSize]
+
+		if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+			data = data[:i+1]
+		}
+	}
+
+	if waitErr := cmd.Wait(); !truncated && waitErr != nil {
+		return "", false, commandError(waitErr, stderr.Bytes())
+	}
+
+	if err != nil && !truncated {
+		return "", false, err
+	}
+
+	return strings.TrimSpace(string(data)), truncated, nil
+}
+
+func run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+
+	if err != nil {
+		return nil, commandError(err, stderr.Bytes())
+	}
+
+	return output, nil
+}
+
+func commandError(err error, stderr []byte) error {
+	if message := strings.TrimSpace(string(stderr)); message != "" {
+		return errors.New(message)
+	}
+
+	return err
+}
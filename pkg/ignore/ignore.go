@@ -0,0 +1,172 @@
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"strings"
+)
+
+// Matcher evaluates gitignore style patterns. Patterns added later take
+// precedence over earlier ones, so files of nested directories must be
+// added after the files of their parents.
+type Matcher struct {
+	patterns []pattern
+}
+
+type pattern struct {
+	base     string
+	glob     string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func New() *Matcher {
+	return &Matcher{}
+}
+
+// AddFile adds the patterns of the ignore file at path. Patterns are relative
+// to base, the slash separated directory of the file below the walk root.
+// Missing files are ignored.
+func (m *Matcher) AddFile(name, base string) error {
+	f, err := os.Open(name)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		m.Add(base, scanner.Text())
+	}
+
+	return scanner.Err()
+}
+
+// Add adds a single pattern line relative to base.
+func (m *Matcher) Add(base, line string) {
+	line = strings.TrimRight(line, "\r")
+
+	if !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimRight(line, " ")
+	}
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	p := pattern{
+		base: strings.Trim(base, "/"),
+	}
+
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+
+	line = strings.TrimPrefix(line, "\\")
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if strings.Contains(line, "/") {
+		p.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	if line == "" {
+		return
+	}
+
+	p.glob = line
+
+	m.patterns = append(m.patterns, p)
+}
+
+// Match reports whether the slash separated path rel is ignored.
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	rel = strings.Trim(rel, "/")
+
+	ignored := false
+
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+
+		name := rel
+
+		if p.base != "" {
+			if !strings.HasPrefix(rel, p.base+"/") {
+				continue
+			}
+
+			name = strings.TrimPrefix(rel, p.base+"/")
+		}
+
+		var matched bool
+
+		if p.anchored {
+			matched = Glob(p.glob, name)
+		} else {
+			matched = Glob(p.glob, path.Base(name))
+		}
+
+		if matched {
+			ignored = !p.negate
+		}
+	}
+
+	return ignored
+}
+
+// Glob reports whether the slash separated name matches pattern. In addition
+// to the syntax of path.Match, a "**" segment matches zero or more segments.
+func Glob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+
+			if len(pattern) == 0 {
+				return true
+			}
+
+			for n := range name {
+				if matchSegments(pattern, name[n:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0
+}
@@ -0,0 +1,197 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+
+		want bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "main.txt", false},
+		{"*.go", "cmd/main.go", false},
+		{"docs/*.md", "docs/readme.md", true},
+		{"docs/*.md", "docs/api/readme.md", false},
+		{"**/*.md", "readme.md", true},
+		{"**/*.md", "docs/api/readme.md", true},
+		{"docs/**", "docs/api/readme.md", true},
+		{"docs/**", "src/main.go", false},
+		{"docs/**/readme.md", "docs/readme.md", true},
+		{"docs/**/readme.md", "docs/a/b/readme.md", true},
+		{"docs/**/readme.md", "docs/a/b/other.md", false},
+		{"file?.txt", "file1.txt", true},
+		{"file[0-9].txt", "filex.txt", false},
+	}
+
+	for _, test := range tests {
+		if got := Glob(test.pattern, test.name); got != test.want {
+			t.Errorf("Glob(%q, %q) = %v, want %v", test.pattern, test.name, got, test.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	type pattern struct {
+		base string
+		line string
+	}
+
+	tests := []struct {
+		name string
+
+		patterns []pattern
+
+		path  string
+		isDir bool
+
+		want bool
+	}{
+		{
+			name:     "name at any depth",
+			patterns: []pattern{{"", "*.log"}},
+			path:     "a/b/debug.log",
+			want:     true,
+		},
+		{
+			name:     "comment",
+			patterns: []pattern{{"", "# *.log"}},
+			path:     "debug.log",
+			want:     false,
+		},
+		{
+			name:     "escaped hash",
+			patterns: []pattern{{"", `\#notes`}},
+			path:     "#notes",
+			want:     true,
+		},
+		{
+			name:     "trailing spaces",
+			patterns: []pattern{{"", "debug.log   "}},
+			path:     "debug.log",
+			want:     true,
+		},
+		{
+			name:     "anchored",
+			patterns: []pattern{{"", "/build"}},
+			path:     "src/build",
+			isDir:    true,
+			want:     false,
+		},
+		{
+			name:     "anchored at root",
+			patterns: []pattern{{"", "/build"}},
+			path:     "build",
+			isDir:    true,
+			want:     true,
+		},
+		{
+			name:     "path with slash",
+			patterns: []pattern{{"", "docs/internal"}},
+			path:     "docs/internal",
+			isDir:    true,
+			want:     true,
+		},
+		{
+			name:     "directory only",
+			patterns: []pattern{{"", "vendor/"}},
+			path:     "vendor",
+			isDir:    false,
+			want:     false,
+		},
+		{
+			name:     "directory",
+			patterns: []pattern{{"", "vendor/"}},
+			path:     "pkg/vendor",
+			isDir:    true,
+			want:     true,
+		},
+		{
+			name:     "negation",
+			patterns: []pattern{{"", "*.log"}, {"", "!keep.log"}},
+			path:     "keep.log",
+			want:     false,
+		},
+		{
+			name:     "later pattern wins",
+			patterns: []pattern{{"", "!keep.log"}, {"", "*.log"}},
+			path:     "keep.log",
+			want:     true,
+		},
+		{
+			name:     "nested file",
+			patterns: []pattern{{"src", "*.tmp"}},
+			path:     "src/a/cache.tmp",
+			want:     true,
+		},
+		{
+			name:     "outside nested file",
+			patterns: []pattern{{"src", "*.tmp"}},
+			path:     "docs/cache.tmp",
+			want:     false,
+		},
+		{
+			name:     "nested file overrides parent",
+			patterns: []pattern{{"", "*.gen.go"}, {"api", "!*.gen.go"}},
+			path:     "api/types.gen.go",
+			want:     false,
+		},
+		{
+			name:     "anchored in nested file",
+			patterns: []pattern{{"src", "/out"}},
+			path:     "src/out",
+			isDir:    true,
+			want:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := New()
+
+			for _, p := range test.patterns {
+				m.Add(p.base, p.line)
+			}
+
+			if got := m.Match(test.path, test.isDir); got != test.want {
+				t.Errorf("Match(%q) = %v, want %v", test.path, got, test.want)
+			}
+		})
+	}
+}
+
+func TestAddFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".gitignore")
+
+	if err := os.WriteFile(path, []byte("# generated\r\n*.out\r\n\r\n!main.out\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New()
+
+	if err := m.AddFile(path, "sub"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.AddFile(filepath.Join(dir, "missing"), ""); err != nil {
+		t.Errorf("missing file: %v", err)
+	}
+
+	if !m.Match("sub/test.out", false) {
+		t.Error("sub/test.out is not ignored")
+	}
+
+	if m.Match("sub/main.out", false) {
+		t.Error("sub/main.out is ignored")
+	}
+
+	if m.Match("test.out", false) {
+		t.Error("test.out outside of the base is ignored")
+	}
+}
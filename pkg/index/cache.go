@@ -0,0 +1,148 @@
+package index
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ncruces/go-sqlite3/gormlite"
+)
+
+var _ Embedder = (*CachedEmbedder)(nil)
+
+// CachedEmbedder wraps an Embedder and stores every vector under the hash of
+// the model and text, so identical texts are only ever embedded once. The
+// model is only known by the name it was requested with: if it is empty, the
+// server's default model is used, and vectors cached before that default
+// changed are still served. Bypass the cache to start over in that case.
+type CachedEmbedder struct {
+	db *gorm.DB
+
+	model    string
+	embedder Embedder
+}
+
+type CacheModel struct {
+	Hash string `gorm:"primaryKey"`
+
+	Vector datatypes.JSONSlice[float32]
+
+	CreatedAt time.Time
+}
+
+func NewCachedEmbedder(path, model string, embedder Embedder) (*CachedEmbedder, error) {
+	db, err := gorm.Open(gormlite.Open(path), &gorm.Config{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&CacheModel{}); err != nil {
+		return nil, err
+	}
+
+	return &CachedEmbedder{
+		db: db,
+
+		model:    model,
+		embedder: embedder,
+	}, nil
+}
+
+func (e *CachedEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	keys := make([]string, len(texts))
+
+	for n, text := range texts {
+		keys[n] = e.hash(text)
+	}
+
+	var models []CacheModel
+
+	if result := e.db.WithContext(ctx).Where("hash IN ?", keys).Find(&models); result.Error != nil {
+		return nil, result.Error
+	}
+
+	vectors := make(map[string][]float32, len(texts))
+
+	for _, m := range models {
+		vectors[m.Hash] = m.Vector
+	}
+
+	var missing []string
+	var missingKeys []string
+
+	for n, key := range keys {
+		if _, ok := vectors[key]; ok {
+			continue
+		}
+
+		// mark as pending so duplicates within this call are embedded once
+		vectors[key] = nil
+
+		missing = append(missing, texts[n])
+		missingKeys = append(missingKeys, key)
+	}
+
+	if len(missing) > 0 {
+		embeddings, err := e.embedder.Embed(ctx, missing)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(embeddings) != len(missing) {
+			return nil, fmt.Errorf("embedder returned %d embeddings for %d texts", len(embeddings), len(missing))
+		}
+
+		var entries []CacheModel
+
+		for n, key := range missingKeys {
+			vectors[key] = embeddings[n]
+
+			entries = append(entries, CacheModel{
+				Hash:   key,
+				Vector: datatypes.NewJSONSlice(embeddings[n]),
+			})
+		}
+
+		result := e.db.WithContext(ctx).Clauses(clause.OnConflict{
+			DoNothing: true,
+		}).CreateInBatches(entries, insertBatchSize)
+
+		if result.Error != nil {
+			return nil, result.Error
+		}
+	}
+
+	result := make([][]float32, len(texts))
+
+	for n, key := range keys {
+		result[n] = vectors[key]
+	}
+
+	return result, nil
+}
+
+func (e *CachedEmbedder) hash(text string) string {
+	h := sha256.New()
+
+	h.Write([]byte(e.model))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,230 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/adrianliechti/wingman/pkg/index"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrModelMismatch     = errors.New("embedding model mismatch")
+	ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+)
+
+const (
+	metaEmbeddingModel      = "embedding_model"
+	metaEmbeddingDimensions = "embedding_dimensions"
+)
+
+type MetaModel struct {
+	Key   string `gorm:"primaryKey"`
+	Value string
+}
+
+// Model returns the embedding model the stored vectors were created with.
+func (i *Index) Model() string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if i.stale {
+		return i.staleModel
+	}
+
+	return i.model
+}
+
+// Dimensions returns the length of the stored vectors, or 0 if the index is empty.
+func (i *Index) Dimensions() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.dimensions
+}
+
+func (i *Index) loadEmbeddingInfo() error {
+	var metas []MetaModel
+
+	if result := i.db.Find(&metas, []string{metaEmbeddingModel, metaEmbeddingDimensions}); result.Error != nil {
+		return result.Error
+	}
+
+	values := map[string]string{}
+
+	for _, m := range metas {
+		values[m.Key] = m.Value
+	}
+
+	model, hasModel := values[metaEmbeddingModel]
+
+	if !hasModel {
+		// databases created before model tracking adopt the model of the
+		// first write
+		return nil
+	}
+
+	i.recorded = true
+
+	if val := values[metaEmbeddingDimensions]; val != "" {
+		dimensions, err := strconv.Atoi(val)
+
+		if err != nil {
+			return err
+		}
+
+		if i.dimensions > 0 && i.dimensions != dimensions {
+			return fmt.Errorf("%w: index records %d dimensions, vectors have %d", ErrDimensionMismatch, dimensions, i.dimensions)
+		}
+
+		i.dimensions = dimensions
+	}
+
+	if model != i.model {
+		i.stale = true
+		i.staleModel = model
+	}
+
+	return nil
+}
+
+func writeEmbeddingInfo(tx *gorm.DB, model string, dimensions int) error {
+	metas := []MetaModel{
+		{Key: metaEmbeddingModel, Value: model},
+		{Key: metaEmbeddingDimensions, Value: strconv.Itoa(dimensions)},
+	}
+
+	result := tx.Clauses(clause.OnConflict{
+		UpdateAll: true,
+	}).Create(&metas)
+
+	return result.Error
+}
+
+// CheckModel reports whether the stored vectors were created with the configured model.
+func (i *Index) CheckModel() error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if !i.stale {
+		return nil
+	}
+
+	return fmt.Errorf("%w: index was embedded with %s, configured model is %s; re-embed the index to switch models", ErrModelMismatch, modelName(i.staleModel), modelName(i.model))
+}
+
+// checkDimensions returns the vector length of the documents, or of the index
+// if it has vectors already. The caller must hold i.mu.
+func (i *Index) checkDimensions(documents []index.Document) (int, error) {
+	expected := i.dimensions
+
+	for _, d := range documents {
+		if len(d.Embedding) == 0 {
+			continue
+		}
+
+		if expected == 0 {
+			expected = len(d.Embedding)
+		}
+
+		if len(d.Embedding) != expected {
+			return 0, fmt.Errorf("%w: document has %d dimensions, expected %d", ErrDimensionMismatch, len(d.Embedding), expected)
+		}
+	}
+
+	return expected, nil
+}
+
+// Reembed recomputes the vectors of all records, in all collections, with the
+// configured embedder and records its model, migrating an index built with a
+// different model.
+// Documents indexed while Reembed is running are not migrated.
+func (i *Index) Reembed(ctx context.Context) error {
+	if i.embedder == nil {
+		return errors.New("no embedder configured")
+	}
+
+	var models []RecordModel
+
+	vectors := make(map[uint][]float32)
+
+	var dimensions int
+
+	result := i.db.WithContext(ctx).Model(&RecordModel{}).FindInBatches(&models, embedBatchSize, func(tx *gorm.DB, batch int) error {
+		texts := make([]string, 0, len(models))
+
+		for _, m := range models {
+			texts = append(texts, m.Text)
+		}
+
+		embeddings, err := i.embedder.Embed(ctx, texts)
+
+		if err != nil {
+			return err
+		}
+
+		if len(embeddings) != len(texts) {
+			return fmt.Errorf("embedder returned %d embeddings for %d texts", len(embeddings), len(texts))
+		}
+
+		for n, m := range models {
+			if dimensions == 0 {
+				dimensions = len(embeddings[n])
+			}
+
+			if len(embeddings[n]) != dimensions {
+				return fmt.Errorf("%w: record %d has %d dimensions, expected %d", ErrDimensionMismatch, m.ID, len(embeddings[n]), dimensions)
+			}
+
+			vectors[m.ID] = embeddings[n]
+		}
+
+		return nil
+	})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	err := i.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for id, vector := range vectors {
+			if result := tx.Model(&RecordModel{}).Where("id = ?", id).Update("vector", datatypes.NewJSONSlice(vector)); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		if dimensions == 0 {
+			return tx.Delete(&MetaModel{}, []string{metaEmbeddingModel, metaEmbeddingDimensions}).Error
+		}
+
+		return writeEmbeddingInfo(tx, i.model, dimensions)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	i.vectors = vectors
+	i.dimensions = dimensions
+	i.recorded = dimensions > 0
+	i.stale = false
+	i.staleModel = ""
+
+	return nil
+}
+
+func modelName(model string) string {
+	if model == "" {
+		return "the default model"
+	}
+
+	return strconv.Quote(model)
+}
@@ -5,10 +5,12 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"slices"
 	"strconv"
+	"sync"
 
 	"github.com/adrianliechti/wingman/pkg/index"
 
@@ -22,34 +24,95 @@ import (
 
 var _ index.Provider = (*Index)(nil)
 
+type (
+	Document = index.Document
+	Result   = index.Result
+
+	ListOptions  = index.ListOptions
+	QueryOptions = index.QueryOptions
+)
+
+const (
+	// upper bound of texts handed to the embedder at once; embedders
+	// may split these further into smaller requests
+	embedBatchSize  = 256
+	insertBatchSize = 100
+)
+
+// DefaultCollection holds the records of indexes opened without collections.
+const DefaultCollection = "default"
+
 type Index struct {
 	db *gorm.DB
 
-	vectors  map[uint][]float32
+	mu      sync.RWMutex
+	vectors map[uint][]float32
+	owners  map[uint]string
+
+	// collections read by the index, records are written to the first
+	collections []string
+
 	embedder Embedder
+
+	model      string
+	dimensions int
+
+	// set once the embedding model is stored in the database
+	recorded bool
+
+	// set if the stored vectors were created with a different model
+	stale      bool
+	staleModel string
+}
+
+type Options struct {
+	// Model is the name of the embedding model behind the embedder
+	Model string
+
+	// Collections scopes the index to named collections within the
+	// database. Records are written to the first one, reads include all of
+	// them. Defaults to DefaultCollection.
+	Collections []string
 }
 
 type Embedder interface {
-	Embed(ctx context.Context, text string) ([]float32, error)
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
 }
 
 type RecordModel struct {
 	gorm.Model
 
+	Collection string `gorm:"index"`
+	Source     string `gorm:"index"`
+
 	Text   string
 	Vector datatypes.JSONSlice[float32]
 
 	Metadata datatypes.JSONMap
 }
 
-func New(path string, embedder Embedder) (*Index, error) {
+func New(path string, embedder Embedder, options *Options) (*Index, error) {
+	if options == nil {
+		options = new(Options)
+	}
+
 	db, err := gorm.Open(gormlite.Open(path), &gorm.Config{})
 
 	if err != nil {
 		return nil, err
 	}
 
-	if err := db.AutoMigrate(&RecordModel{}); err != nil {
+	sqlDB, err := db.DB()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite allows a single writer only; sharing one connection serializes
+	// concurrent queries and writes instead of failing with SQLITE_BUSY
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&RecordModel{}, &MetaModel{}); err != nil {
 		return nil, err
 	}
 
@@ -57,13 +120,34 @@ func New(path string, embedder Embedder) (*Index, error) {
 		db: db,
 
 		vectors:  make(map[uint][]float32),
+		owners:   make(map[uint]string),
 		embedder: embedder,
+
+		model: options.Model,
+
+		collections: options.Collections,
+	}
+
+	if len(i.collections) == 0 {
+		i.collections = []string{DefaultCollection}
+	}
+
+	if err := i.migrateSources(); err != nil {
+		return nil, err
+	}
+
+	if err := i.migrateCollections(); err != nil {
+		return nil, err
 	}
 
 	if err := i.indexEmbeddings(); err != nil {
 		return nil, err
 	}
 
+	if err := i.loadEmbeddingInfo(); err != nil {
+		return nil, err
+	}
+
 	return i, nil
 }
 
@@ -76,7 +160,16 @@ func (i *Index) indexEmbeddings() error {
 				continue
 			}
 
+			if i.dimensions == 0 {
+				i.dimensions = len(m.Vector)
+			}
+
+			if len(m.Vector) != i.dimensions {
+				return fmt.Errorf("%w: record %d has %d dimensions, expected %d", ErrDimensionMismatch, m.ID, len(m.Vector), i.dimensions)
+			}
+
 			i.vectors[m.ID] = m.Vector
+			i.owners[m.ID] = m.Collection
 		}
 
 		return nil
@@ -125,28 +218,17 @@ func (i *Index) List(ctx context.Context, options *index.ListOptions) (*index.Pa
 
 	var records []RecordModel
 
-	if result := i.db.Offset(offset).Limit(limit).Find(&records); result.Error != nil {
+	if result := i.db.WithContext(ctx).Where("collection IN ?", i.collections).Order("id").Offset(offset).Limit(limit).Find(&records); result.Error != nil {
 		return nil, result.Error
 	}
 
 	page := &index.Page[index.Document]{}
 
 	for _, r := range records {
-		metadata := map[string]string{}
-
-		for k, v := range r.Metadata {
-			metadata[k] = v.(string)
-		}
-
-		page.Items = append(page.Items, index.Document{
-			ID: fmt.Sprintf("%d", r.ID),
-
-			Content: r.Text,
+		document := toDocument(r)
+		document.Embedding = r.Vector
 
-			Metadata: metadata,
-
-			Embedding: r.Vector,
-		})
+		page.Items = append(page.Items, document)
 	}
 
 	c := cursor{
@@ -161,19 +243,32 @@ func (i *Index) List(ctx context.Context, options *index.ListOptions) (*index.Pa
 }
 
 func (i *Index) Index(ctx context.Context, documents ...index.Document) error {
-	for _, d := range documents {
-		m := &RecordModel{
-			Text: d.Content,
+	return i.write(ctx, nil, documents)
+}
+
+func (i *Index) write(ctx context.Context, replace []string, documents []index.Document) error {
+	if len(documents) == 0 && len(replace) == 0 {
+		return nil
+	}
+
+	if len(documents) > 0 {
+		if err := i.CheckModel(); err != nil {
+			return err
 		}
 
-		if len(d.Embedding) == 0 && i.embedder != nil {
-			embedding, err := i.embedder.Embed(ctx, d.Content)
+		if err := i.embed(ctx, documents); err != nil {
+			return err
+		}
+	}
 
-			if err != nil {
-				return err
-			}
+	models := make([]*RecordModel, 0, len(documents))
+
+	for _, d := range documents {
+		m := &RecordModel{
+			Collection: i.collections[0],
+			Source:     d.Source,
 
-			d.Embedding = embedding
+			Text: d.Content,
 		}
 
 		if len(d.Embedding) > 0 {
@@ -190,16 +285,110 @@ func (i *Index) Index(ctx context.Context, documents ...index.Document) error {
 			m.Metadata = metadata
 		}
 
-		result := i.db.Clauses(clause.OnConflict{
+		models = append(models, m)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	dimensions, err := i.checkDimensions(documents)
+
+	if err != nil {
+		return err
+	}
+
+	var removed []uint
+
+	err = i.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(replace) > 0 {
+			if result := tx.Model(&RecordModel{}).Where("collection = ? AND source IN ?", i.collections[0], replace).Pluck("id", &removed); result.Error != nil {
+				return result.Error
+			}
+
+			if len(removed) > 0 {
+				if result := tx.Unscoped().Delete(&RecordModel{}, removed); result.Error != nil {
+					return result.Error
+				}
+			}
+		}
+
+		if len(models) == 0 {
+			return nil
+		}
+
+		if !i.recorded && dimensions > 0 {
+			if err := writeEmbeddingInfo(tx, i.model, dimensions); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Clauses(clause.OnConflict{
 			UpdateAll: true,
-		}).Create(&m)
+		}).CreateInBatches(models, insertBatchSize)
+
+		return result.Error
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if dimensions > 0 {
+		i.dimensions = dimensions
+		i.recorded = true
+	}
+
+	for _, id := range removed {
+		delete(i.vectors, id)
+		delete(i.owners, id)
+	}
 
-		if result.Error != nil {
-			return result.Error
+	for _, m := range models {
+		if len(m.Vector) == 0 {
+			continue
 		}
 
+		i.vectors[m.ID] = m.Vector
+		i.owners[m.ID] = m.Collection
+	}
+
+	return nil
+}
+
+func (i *Index) embed(ctx context.Context, documents []index.Document) error {
+	if i.embedder == nil {
+		return nil
+	}
+
+	var pending []int
+
+	for n, d := range documents {
 		if len(d.Embedding) > 0 {
-			i.vectors[m.ID] = d.Embedding
+			continue
+		}
+
+		pending = append(pending, n)
+	}
+
+	for batch := range slices.Chunk(pending, embedBatchSize) {
+		texts := make([]string, 0, len(batch))
+
+		for _, n := range batch {
+			texts = append(texts, documents[n].Content)
+		}
+
+		embeddings, err := i.embedder.Embed(ctx, texts)
+
+		if err != nil {
+			return err
+		}
+
+		if len(embeddings) != len(texts) {
+			return fmt.Errorf("embedder returned %d embeddings for %d texts", len(embeddings), len(texts))
+		}
+
+		for k, n := range batch {
+			documents[n].Embedding = embeddings[k]
 		}
 	}
 
@@ -211,12 +400,26 @@ func (i *Index) Query(ctx context.Context, query string, options *index.QueryOpt
 		options = new(index.QueryOptions)
 	}
 
-	vector, err := i.embedder.Embed(ctx, query)
+	if err := i.CheckModel(); err != nil {
+		return nil, err
+	}
+
+	embeddings, err := i.embedder.Embed(ctx, []string{query})
 
 	if err != nil {
 		return nil, err
 	}
 
+	if len(embeddings) == 0 {
+		return nil, errors.New("embedder returned no embedding")
+	}
+
+	vector := embeddings[0]
+
+	if d := i.Dimensions(); d > 0 && len(vector) != d {
+		return nil, fmt.Errorf("%w: query has %d dimensions, index has %d", ErrDimensionMismatch, len(vector), d)
+	}
+
 	limit := 10
 
 	if options.Limit != nil {
@@ -228,9 +431,15 @@ func (i *Index) Query(ctx context.Context, query string, options *index.QueryOpt
 		score float64
 	}
 
+	i.mu.RLock()
+
 	scores := make([]scoredID, 0, len(i.vectors))
 
 	for k, v := range i.vectors {
+		if !slices.Contains(i.collections, i.owners[k]) {
+			continue
+		}
+
 		score := similarity(vector, v)
 
 		scores = append(scores, scoredID{
@@ -239,6 +448,8 @@ func (i *Index) Query(ctx context.Context, query string, options *index.QueryOpt
 		})
 	}
 
+	i.mu.RUnlock()
+
 	slices.SortFunc(scores, func(a, b scoredID) int {
 		return cmp.Compare(b.score, a.score)
 	})
@@ -259,28 +470,19 @@ func (i *Index) Query(ctx context.Context, query string, options *index.QueryOpt
 
 	var models []RecordModel
 
-	if result := i.db.Find(&models, conds); result.Error != nil {
+	if result := i.db.WithContext(ctx).Find(&models, conds); result.Error != nil {
 		return nil, result.Error
 	}
 
 	var results []index.Result
 
 	for _, m := range models {
-		metadata := map[string]string{}
-
-		for k, v := range m.Metadata {
-			metadata[k] = v.(string)
-		}
-
 		result := index.Result{
-			Document: index.Document{
-				ID: fmt.Sprintf("%d", m.ID),
-
-				Content:  m.Text,
-				Metadata: metadata,
-			},
+			Document: toDocument(m),
 		}
 
+		result.Embedding = m.Vector
+
 		for _, s := range scores {
 			if s.ID != m.ID {
 				continue
@@ -292,6 +494,10 @@ func (i *Index) Query(ctx context.Context, query string, options *index.QueryOpt
 		results = append(results, result)
 	}
 
+	slices.SortFunc(results, func(a, b index.Result) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
 	return results, nil
 }
 
@@ -308,11 +514,54 @@ func (i *Index) Delete(ctx context.Context, ids ...string) error {
 		conds = append(conds, uint(val))
 	}
 
-	result := i.db.Unscoped().Delete(&RecordModel{}, conds)
-	return result.Error
+	if len(conds) == 0 {
+		return nil
+	}
+
+	if result := i.db.WithContext(ctx).Unscoped().Delete(&RecordModel{}, conds); result.Error != nil {
+		return result.Error
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, id := range conds {
+		delete(i.vectors, id)
+		delete(i.owners, id)
+	}
+
+	return nil
+}
+
+// Vacuum rebuilds the database file to reclaim the space of deleted records.
+func (i *Index) Vacuum(ctx context.Context) error {
+	return i.db.WithContext(ctx).Exec("VACUUM").Error
+}
+
+func toDocument(m RecordModel) index.Document {
+	metadata := map[string]string{}
+
+	for k, v := range m.Metadata {
+		if s, ok := v.(string); ok {
+			metadata[k] = s
+		}
+	}
+
+	return index.Document{
+		ID: fmt.Sprintf("%d", m.ID),
+
+		Source:  m.Source,
+		Content: m.Text,
+
+		Metadata: metadata,
+	}
 }
 
 func similarity(vals1, vals2 []float32) float64 {
+	if len(vals1) != len(vals2) {
+		return 0
+	}
+
 	l2norm := func(v float64, s, t float64) (float64, float64) {
 		if v == 0 {
 			return s, t
@@ -0,0 +1,171 @@
+package index
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+
+	"github.com/adrianliechti/wingman/pkg/index"
+
+	"gorm.io/gorm"
+)
+
+// Source summarizes the records indexed from one file or resource.
+type Source struct {
+	Collection string
+
+	Name     string
+	Revision string
+
+	// Kind tells what the source is, e.g. a file or a resource
+	Kind string
+
+	// Chunking describes how the records were split from the source
+	Chunking string
+
+	Chunks int
+}
+
+// Sources returns the sources of the collections of the index ordered by
+// collection and name.
+func (i *Index) Sources(ctx context.Context) ([]Source, error) {
+	return i.sources(ctx, i.collections)
+}
+
+// WriteSources returns the sources of the collection written to ordered by
+// name. Indexing compares against these, as sources of the other collections
+// are never replaced or deleted.
+func (i *Index) WriteSources(ctx context.Context) ([]Source, error) {
+	return i.sources(ctx, i.collections[:1])
+}
+
+func (i *Index) sources(ctx context.Context, collections []string) ([]Source, error) {
+	var rows []struct {
+		Collection string
+		Source     string
+		Revision   string
+		Kind       string
+		Chunking   string
+		Chunks     int
+	}
+
+	result := i.db.WithContext(ctx).Model(&RecordModel{}).
+		Select("collection, source, MAX(json_extract(metadata, '$.revision')) AS revision, MAX(json_extract(metadata, '$.kind')) AS kind, MAX(json_extract(metadata, '$.chunking')) AS chunking, COUNT(*) AS chunks").
+		Where("collection IN ? AND source <> ''", collections).
+		Group("collection, source").
+		Order("collection, source").
+		Scan(&rows)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	sources := make([]Source, 0, len(rows))
+
+	for _, r := range rows {
+		sources = append(sources, Source{
+			Collection: r.Collection,
+
+			Name:     r.Source,
+			Revision: r.Revision,
+			Kind:     r.Kind,
+			Chunking: r.Chunking,
+
+			Chunks: r.Chunks,
+		})
+	}
+
+	return sources, nil
+}
+
+// Documents returns all records of source in the collections of the index
+// ordered by their chunk index.
+func (i *Index) Documents(ctx context.Context, source string) ([]index.Document, error) {
+	return i.documents(ctx, i.collections, source)
+}
+
+// WriteDocuments returns the records of source in the collection written to
+// ordered by their chunk index.
+func (i *Index) WriteDocuments(ctx context.Context, source string) ([]index.Document, error) {
+	return i.documents(ctx, i.collections[:1], source)
+}
+
+func (i *Index) documents(ctx context.Context, collections []string, source string) ([]index.Document, error) {
+	var models []RecordModel
+
+	if result := i.db.WithContext(ctx).Where("collection IN ? AND source = ?", collections, source).Order("id").Find(&models); result.Error != nil {
+		return nil, result.Error
+	}
+
+	documents := make([]index.Document, 0, len(models))
+
+	for _, m := range models {
+		documents = append(documents, toDocument(m))
+	}
+
+	slices.SortStableFunc(documents, func(a, b index.Document) int {
+		x, _ := strconv.Atoi(a.Metadata["index"])
+		y, _ := strconv.Atoi(b.Metadata["index"])
+
+		return cmp.Compare(x, y)
+	})
+
+	return documents, nil
+}
+
+// Replace atomically swaps all records of source in the collection written
+// to with documents.
+func (i *Index) Replace(ctx context.Context, source string, documents ...index.Document) error {
+	for n := range documents {
+		documents[n].Source = source
+	}
+
+	return i.write(ctx, []string{source}, documents)
+}
+
+// DeleteSources removes all records of the given sources from the collection
+// written to.
+func (i *Index) DeleteSources(ctx context.Context, sources ...string) error {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	return i.write(ctx, sources, nil)
+}
+
+// migrateSources fills the source column of records written before it
+// existed from their path or uri metadata.
+func (i *Index) migrateSources() error {
+	result := i.db.Model(&RecordModel{}).
+		Where("source IS NULL OR source = ''").
+		Update("source", gorm.Expr("COALESCE(json_extract(metadata, '$.path'), json_extract(metadata, '$.uri'), '')"))
+
+	return result.Error
+}
+
+// Collections returns the names of all collections in the database.
+func (i *Index) Collections(ctx context.Context) ([]string, error) {
+	var names []string
+
+	if result := i.db.WithContext(ctx).Model(&RecordModel{}).Distinct().Order("collection").Pluck("collection", &names); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return names, nil
+}
+
+// migrateCollections moves records written before collections existed to
+// the default collection.
+func (i *Index) migrateCollections() error {
+	result := i.db.Model(&RecordModel{}).
+		Where("collection IS NULL OR collection = ''").
+		Update("collection", DefaultCollection)
+
+	return result.Error
+}
+
+// Collection returns the collection the index writes to.
+func (i *Index) Collection() string {
+	return i.collections[0]
+}
@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"os/exec"
@@ -11,16 +12,32 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// Client keeps a session open per configured server, which is shared by all
+// calls to that server. Close the client to shut the sessions down.
 type Client struct {
-	transports map[string]func() (mcp.Transport, error)
+	servers map[string]*server
 }
 
 func New(config *Config) (*Client, error) {
 	c := &Client{
-		transports: make(map[string]func() (mcp.Transport, error)),
+		servers: make(map[string]*server),
 	}
 
 	for n, s := range config.Servers {
+		idleTimeout := DefaultIdleTimeout
+
+		if s.IdleTimeout != "" {
+			d, err := time.ParseDuration(s.IdleTimeout)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid idle timeout of server %s: %w", n, err)
+			}
+
+			idleTimeout = d
+		}
+
+		var transport func(ctx context.Context) (mcp.Transport, error)
+
 		switch s.Type {
 		case "stdio", "command":
 			env := os.Environ()
@@ -29,8 +46,10 @@ func New(config *Config) (*Client, error) {
 				env = append(env, k+"="+v)
 			}
 
-			c.transports[n] = func() (mcp.Transport, error) {
-				cmd := exec.Command(s.Command, s.Args...)
+			transport = func(ctx context.Context) (mcp.Transport, error) {
+				// the process is killed if ctx ends, which only happens
+				// when the handshake is aborted
+				cmd := exec.CommandContext(ctx, s.Command, s.Args...)
 				cmd.Env = env
 
 				return mcp.NewCommandTransport(cmd), nil
@@ -47,7 +66,7 @@ func New(config *Config) (*Client, error) {
 				}
 			}
 
-			c.transports[n] = func() (mcp.Transport, error) {
+			transport = func(ctx context.Context) (mcp.Transport, error) {
 				transport := mcp.NewStreamableClientTransport(s.URL, &mcp.StreamableClientTransportOptions{
 					HTTPClient: client,
 				})
@@ -66,7 +85,7 @@ func New(config *Config) (*Client, error) {
 				}
 			}
 
-			c.transports[n] = func() (mcp.Transport, error) {
+			transport = func(ctx context.Context) (mcp.Transport, error) {
 				transport := mcp.NewSSEClientTransport(s.URL, &mcp.SSEClientTransportOptions{
 					HTTPClient: client,
 				})
@@ -77,36 +96,14 @@ func New(config *Config) (*Client, error) {
 		default:
 			return nil, errors.New("invalid server type")
 		}
-	}
-
-	return c, nil
-}
 
-func (c *Client) createSession(ctx context.Context, server string) (*mcp.ClientSession, error) {
-	transportFn, ok := c.transports[server]
-
-	if !ok {
-		return nil, errors.New("unknown server: " + server)
-	}
-
-	transport, err := transportFn()
-
-	if err != nil {
-		return nil, err
-	}
-
-	impl := &mcp.Implementation{
-		Name:    "wingman",
-		Version: "1.0.0",
-	}
-
-	opts := &mcp.ClientOptions{
-		KeepAlive: time.Second * 30,
+		c.servers[n] = &server{
+			transport:   transport,
+			idleTimeout: idleTimeout,
+		}
 	}
 
-	client := mcp.NewClient(impl, opts)
-
-	return client.Connect(ctx, transport)
+	return c, nil
 }
 
 type rt struct {
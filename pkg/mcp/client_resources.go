@@ -12,22 +12,26 @@ import (
 func (c *Client) Resources(ctx context.Context) ([]resource.Resource, error) {
 	var result []resource.Resource
 
-	for name := range c.transports {
-		session, err := c.createSession(ctx, name)
+	for name := range c.servers {
+		var list []*mcp.Resource
 
-		if err != nil {
-			return nil, err
-		}
+		if err := c.call(ctx, name, func(session *mcp.ClientSession) error {
+			list = nil
 
-		defer session.Close()
+			for r, err := range session.Resources(ctx, nil) {
+				if err != nil {
+					return err
+				}
 
-		resp, err := session.ListResources(ctx, nil)
+				list = append(list, r)
+			}
 
-		if err != nil {
+			return nil
+		}); err != nil {
 			return nil, err
 		}
 
-		for _, r := range resp.Resources {
+		for _, r := range list {
 			resource := resource.Resource{
 				URI: r.URI,
 
@@ -37,44 +41,96 @@ func (c *Client) Resources(ctx context.Context) ([]resource.Resource, error) {
 				ContentType: r.MIMEType,
 
 				Content: func(ctx context.Context) ([]byte, error) {
-					session, err := c.createSession(ctx, name)
+					return c.readResource(ctx, name, r.URI)
+				},
+			}
 
-					if err != nil {
-						return nil, err
-					}
+			result = append(result, resource)
+		}
+	}
 
-					defer session.Close()
+	return result, nil
+}
+
+// ResourceTemplates returns the resource templates of all servers. Servers
+// that fail to list templates, e.g. because they have none, are skipped.
+func (c *Client) ResourceTemplates(ctx context.Context) ([]resource.Template, error) {
+	var result []resource.Template
+
+	for name := range c.servers {
+		var list []*mcp.ResourceTemplate
+
+		if err := c.call(ctx, name, func(session *mcp.ClientSession) error {
+			list = nil
 
-					resp, err := session.ReadResource(ctx, &mcp.ReadResourceParams{
-						URI: r.URI,
-					})
+			for t, err := range session.ResourceTemplates(ctx, nil) {
+				if err != nil {
+					list = nil
+					break
+				}
+
+				list = append(list, t)
+			}
+
+			return nil
+		}); err != nil {
+			return nil, err
+		}
 
-					if err != nil {
-						return nil, err
-					}
+		var templates []resource.Template
 
-					if len(resp.Contents) > 1 {
-						return nil, errors.New("multiple contents not supported")
-					}
+		for _, t := range list {
+			template := resource.Template{
+				URITemplate: t.URITemplate,
 
-					if len(resp.Contents) == 1 {
-						content := resp.Contents[0]
-						if len(content.Blob) > 0 {
-							return content.Blob, nil
-						}
+				Name:        t.Name,
+				Description: t.Description,
 
-						if len(content.Text) > 0 {
-							return []byte(content.Text), nil
-						}
-					}
+				ContentType: t.MIMEType,
 
-					return nil, errors.New("no content returned")
+				Read: func(ctx context.Context, uri string) ([]byte, error) {
+					return c.readResource(ctx, name, uri)
 				},
 			}
 
-			result = append(result, resource)
+			templates = append(templates, template)
 		}
+
+		result = append(result, templates...)
 	}
 
 	return result, nil
 }
+
+func (c *Client) readResource(ctx context.Context, server, uri string) ([]byte, error) {
+	var resp *mcp.ReadResourceResult
+
+	if err := c.call(ctx, server, func(session *mcp.ClientSession) error {
+		var err error
+
+		resp, err = session.ReadResource(ctx, &mcp.ReadResourceParams{
+			URI: uri,
+		})
+
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Contents) > 1 {
+		return nil, errors.New("multiple contents not supported")
+	}
+
+	if len(resp.Contents) == 1 {
+		content := resp.Contents[0]
+		if len(content.Blob) > 0 {
+			return content.Blob, nil
+		}
+
+		if len(content.Text) > 0 {
+			return []byte(content.Text), nil
+		}
+	}
+
+	return nil, errors.New("no content returned")
+}
@@ -14,18 +14,14 @@ import (
 func (c *Client) Tools(ctx context.Context) ([]tool.Tool, error) {
 	var result []tool.Tool
 
-	for name := range c.transports {
-		session, err := c.createSession(ctx, name)
-
-		if err != nil {
-			return nil, err
-		}
-
-		defer session.Close()
-
-		resp, err := session.ListTools(ctx, nil)
-
-		if err != nil {
+	for name := range c.servers {
+		var resp *mcp.ListToolsResult
+
+		if err := c.call(ctx, name, func(session *mcp.ClientSession) error {
+			var err error
+			resp, err = session.ListTools(ctx, nil)
+			return err
+		}); err != nil {
 			return nil, err
 		}
 
@@ -57,20 +53,18 @@ func (c *Client) Tools(ctx context.Context) ([]tool.Tool, error) {
 						args = map[string]any{}
 					}
 
-					session, err := c.createSession(ctx, name)
-
-					if err != nil {
-						return nil, err
-					}
+					var resp *mcp.CallToolResult
 
-					defer session.Close()
+					if err := c.callOnce(ctx, name, func(session *mcp.ClientSession) error {
+						var err error
 
-					resp, err := session.CallTool(ctx, &mcp.CallToolParams{
-						Name:      t.Name,
-						Arguments: args,
-					})
+						resp, err = session.CallTool(ctx, &mcp.CallToolParams{
+							Name:      t.Name,
+							Arguments: args,
+						})
 
-					if err != nil {
+						return err
+					}); err != nil {
 						return nil, err
 					}
 
@@ -41,4 +41,8 @@ type Server struct {
 	Command string            `json:"command" yaml:"command"`
 	Env     map[string]string `json:"env" yaml:"env"`
 	Args    []string          `json:"args" yaml:"args"`
+
+	// IdleTimeout closes the session after the server was not used for
+	// the duration, e.g. "10m"; "0" keeps it open until exit
+	IdleTimeout string `json:"idleTimeout" yaml:"idleTimeout"`
 }
@@ -0,0 +1,322 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// DefaultIdleTimeout is how long a session is kept open without any calls
+// unless the server configures its own idle timeout.
+const DefaultIdleTimeout = 5 * time.Minute
+
+var ErrClosed = errors.New("mcp client closed")
+
+// server holds the long-lived session to a configured server. The session is
+// opened on first use, opened again once the connection is lost and closed
+// after being idle for idleTimeout.
+type server struct {
+	transport   func(ctx context.Context) (mcp.Transport, error)
+	idleTimeout time.Duration
+
+	mu sync.Mutex
+
+	session *mcp.ClientSession
+	done    chan struct{}
+
+	// set while connecting, cancel aborts the handshake
+	connecting chan struct{}
+	cancel     context.CancelFunc
+
+	active int
+	idle   *time.Timer
+
+	closed bool
+}
+
+// acquire returns the open session, connecting if there is none or the
+// connection was lost. Each acquire must be followed by a release. The lock
+// is not held while connecting, so a server hanging in its handshake only
+// blocks callers of that server until their contexts are done.
+func (s *server) acquire(ctx context.Context) (*mcp.ClientSession, error) {
+	for {
+		s.mu.Lock()
+
+		if s.closed {
+			s.mu.Unlock()
+			return nil, ErrClosed
+		}
+
+		if s.idle != nil {
+			s.idle.Stop()
+			s.idle = nil
+		}
+
+		if s.session != nil && !isDone(s.done) {
+			s.active++
+
+			session := s.session
+			s.mu.Unlock()
+
+			return session, nil
+		}
+
+		// another call is connecting, use its session once it is done
+		if s.connecting != nil {
+			connecting := s.connecting
+			s.mu.Unlock()
+
+			select {
+			case <-connecting:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		stale := s.session
+		s.session = nil
+
+		// the session outlives the call it is opened for, it is only bound
+		// to ctx until connected
+		connCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		connecting := make(chan struct{})
+
+		s.cancel = cancel
+		s.connecting = connecting
+
+		s.mu.Unlock()
+
+		if stale != nil {
+			stale.Close()
+		}
+
+		session, done, err := s.connect(ctx, connCtx, cancel)
+
+		s.mu.Lock()
+
+		s.connecting = nil
+		s.cancel = nil
+
+		close(connecting)
+
+		if err == nil && s.closed {
+			err = ErrClosed
+		}
+
+		if err != nil {
+			s.mu.Unlock()
+
+			// kill a stdio server first, closing waits for it to exit
+			cancel()
+
+			if session != nil {
+				session.Close()
+			}
+
+			return nil, err
+		}
+
+		s.session = session
+		s.done = done
+
+		s.active++
+
+		s.mu.Unlock()
+
+		return session, nil
+	}
+}
+
+// connect opens a session with connCtx, giving up if ctx is done before the
+// handshake finished.
+func (s *server) connect(ctx, connCtx context.Context, cancel context.CancelFunc) (*mcp.ClientSession, chan struct{}, error) {
+	transport, err := s.transport(connCtx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	impl := &mcp.Implementation{
+		Name:    "wingman",
+		Version: "1.0.0",
+	}
+
+	opts := &mcp.ClientOptions{
+		KeepAlive: time.Second * 30,
+	}
+
+	client := mcp.NewClient(impl, opts)
+
+	stop := context.AfterFunc(ctx, cancel)
+
+	session, err := client.Connect(connCtx, transport)
+
+	if !stop() && err == nil {
+		return session, nil, ctx.Err()
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+
+		return nil, nil, err
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		session.Wait()
+		close(done)
+	}()
+
+	return session, done, nil
+}
+
+// release marks a call on session as finished and, once no calls are left,
+// starts the idle timer.
+func (s *server) release(session *mcp.ClientSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active--
+
+	if s.active > 0 || s.session != session || s.idleTimeout <= 0 {
+		return
+	}
+
+	s.idle = time.AfterFunc(s.idleTimeout, func() {
+		s.mu.Lock()
+
+		if s.session != session || s.active > 0 {
+			s.mu.Unlock()
+			return
+		}
+
+		s.session = nil
+		s.idle = nil
+
+		s.mu.Unlock()
+
+		session.Close()
+	})
+}
+
+// reset drops session if it is still the current one, so the next call
+// connects again.
+func (s *server) reset(session *mcp.ClientSession) {
+	s.mu.Lock()
+
+	if s.session != session {
+		s.mu.Unlock()
+		return
+	}
+
+	s.session = nil
+	s.mu.Unlock()
+
+	session.Close()
+}
+
+func (s *server) close() error {
+	s.mu.Lock()
+
+	s.closed = true
+
+	if s.idle != nil {
+		s.idle.Stop()
+		s.idle = nil
+	}
+
+	// abort a pending handshake, the caller closes its session
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	session := s.session
+	s.session = nil
+
+	s.mu.Unlock()
+
+	if session == nil {
+		return nil
+	}
+
+	return session.Close()
+}
+
+// call runs fn with the session of the named server. If the connection turns
+// out to be closed, e.g. because the server process exited, the server is
+// connected again and fn is retried once. Only use it for calls without side
+// effects, such as listing or reading.
+func (c *Client) call(ctx context.Context, name string, fn func(session *mcp.ClientSession) error) error {
+	return c.run(ctx, name, true, fn)
+}
+
+// callOnce runs fn with the session of the named server. It is only retried
+// if the request could not be written, as the server may otherwise have acted
+// on it before the connection was lost.
+func (c *Client) callOnce(ctx context.Context, name string, fn func(session *mcp.ClientSession) error) error {
+	return c.run(ctx, name, false, fn)
+}
+
+func (c *Client) run(ctx context.Context, name string, retry bool, fn func(session *mcp.ClientSession) error) error {
+	s, ok := c.servers[name]
+
+	if !ok {
+		return errors.New("unknown server: " + name)
+	}
+
+	for attempt := 1; ; attempt++ {
+		session, err := s.acquire(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		err = fn(session)
+
+		s.release(session)
+
+		// a broken pipe means the request was never sent
+		unsent := errors.Is(err, syscall.EPIPE)
+
+		if !unsent && !errors.Is(err, mcp.ErrConnectionClosed) {
+			return err
+		}
+
+		s.reset(session)
+
+		if attempt > 1 || !(retry || unsent) {
+			return err
+		}
+	}
+}
+
+// Close closes the sessions of all servers; stdio servers get their stdin
+// closed and are terminated if they do not exit on their own.
+func (c *Client) Close() error {
+	var result error
+
+	for _, s := range c.servers {
+		if err := s.close(); err != nil {
+			result = errors.Join(result, err)
+		}
+	}
+
+	return result
+}
+
+func isDone(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -2,9 +2,12 @@ package resource
 
 import (
 	"context"
+	"regexp"
+	"strings"
 )
 
 type ContentFn func(ctx context.Context) ([]byte, error)
+type ReadFn func(ctx context.Context, uri string) ([]byte, error)
 
 type Resource struct {
 	URI string
@@ -15,3 +18,69 @@ type Resource struct {
 	Content     ContentFn
 	ContentType string
 }
+
+// Template describes a family of resources by an RFC 6570 uri template.
+type Template struct {
+	URITemplate string
+
+	Name        string
+	Description string
+
+	Read        ReadFn
+	ContentType string
+}
+
+var expressionPattern = regexp.MustCompile(`\{([+#./;?&]?)[^}]*\}`)
+
+// Match reports whether uri is an instance of the template. Simple
+// expressions like {name} match a single path segment, reserved and path
+// expansions like {+path} or {/path*} match any text.
+func (t *Template) Match(uri string) bool {
+	var pattern strings.Builder
+
+	pattern.WriteString("^")
+
+	last := 0
+
+	for _, m := range expressionPattern.FindAllStringSubmatchIndex(t.URITemplate, -1) {
+		pattern.WriteString(regexp.QuoteMeta(t.URITemplate[last:m[0]]))
+
+		switch t.URITemplate[m[2]:m[3]] {
+		case "":
+			pattern.WriteString(`[^/?#]+`)
+		case "?", "&", "#":
+			pattern.WriteString(`.*`)
+		default:
+			pattern.WriteString(`.+`)
+		}
+
+		last = m[1]
+	}
+
+	pattern.WriteString(regexp.QuoteMeta(t.URITemplate[last:]))
+	pattern.WriteString("$")
+
+	re, err := regexp.Compile(pattern.String())
+
+	if err != nil {
+		return false
+	}
+
+	return re.MatchString(uri)
+}
+
+// Resource returns the instance of the template identified by uri.
+func (t *Template) Resource(uri string) Resource {
+	return Resource{
+		URI: uri,
+
+		Name:        t.Name,
+		Description: t.Description,
+
+		Content: func(ctx context.Context) ([]byte, error) {
+			return t.Read(ctx, uri)
+		},
+
+		ContentType: t.ContentType,
+	}
+}
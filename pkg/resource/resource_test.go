@@ -0,0 +1,67 @@
+package resource
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTemplateMatch(t *testing.T) {
+	tests := []struct {
+		template string
+		uri      string
+
+		want bool
+	}{
+		{"file:///docs/{name}", "file:///docs/readme.md", true},
+		{"file:///docs/{name}", "file:///docs/api/readme.md", false},
+		{"file:///docs/{name}", "file:///docs/", false},
+		{"file:///docs/{+path}", "file:///docs/api/readme.md", true},
+		{"file:///docs{/path*}", "file:///docs/api/readme.md", true},
+		{"db://{schema}/tables/{table}", "db://public/tables/users", true},
+		{"db://{schema}/tables/{table}", "db://public/views/users", false},
+		{"search://docs{?q,limit}", "search://docs?q=test&limit=5", true},
+		{"search://docs{?q,limit}", "search://docs", true},
+		{"page://{id}.html", "page://42.html", true},
+		{"page://{id}.html", "page://42xhtml", false},
+		{"note://{id}", "note://a?b", false},
+		{"static://readme", "static://readme", true},
+		{"static://readme", "static://readme.md", false},
+	}
+
+	for _, test := range tests {
+		tmpl := &Template{URITemplate: test.template}
+
+		if got := tmpl.Match(test.uri); got != test.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", test.template, test.uri, got, test.want)
+		}
+	}
+}
+
+func TestTemplateResource(t *testing.T) {
+	tmpl := &Template{
+		URITemplate: "note://{id}",
+
+		Name:        "note",
+		ContentType: "text/plain",
+
+		Read: func(ctx context.Context, uri string) ([]byte, error) {
+			return []byte("content of " + uri), nil
+		},
+	}
+
+	r := tmpl.Resource("note://42")
+
+	if r.URI != "note://42" || r.Name != "note" || r.ContentType != "text/plain" {
+		t.Errorf("unexpected resource %+v", r)
+	}
+
+	data, err := r.Content(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "content of note://42" {
+		t.Errorf("content %q, want %q", data, "content of note://42")
+	}
+}
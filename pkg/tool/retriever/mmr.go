@@ -0,0 +1,101 @@
+package retriever
+
+import (
+	"math"
+	"strings"
+
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+)
+
+// mmr selects limit results by maximal marginal relevance: each step picks
+// the candidate with the best trade-off between its score and its
+// similarity to the results picked so far. lambda 1 ranks by score only.
+func mmr(candidates []index.Result, limit int, lambda float64) []index.Result {
+	if len(candidates) <= 1 {
+		return candidates
+	}
+
+	remaining := make([]index.Result, len(candidates))
+	copy(remaining, candidates)
+
+	var selected []index.Result
+
+	for len(selected) < limit && len(remaining) > 0 {
+		best := 0
+		bestValue := math.Inf(-1)
+
+		for n, c := range remaining {
+			redundancy := 0.0
+
+			for _, s := range selected {
+				redundancy = max(redundancy, documentSimilarity(c.Document, s.Document))
+			}
+
+			value := lambda*float64(c.Score) - (1-lambda)*redundancy
+
+			if value > bestValue {
+				best = n
+				bestValue = value
+			}
+		}
+
+		selected = append(selected, remaining[best])
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+
+	return selected
+}
+
+func documentSimilarity(a, b index.Document) float64 {
+	if len(a.Embedding) > 0 && len(a.Embedding) == len(b.Embedding) {
+		return cosine(a.Embedding, b.Embedding)
+	}
+
+	return jaccard(a.Content, b.Content)
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+
+		dot += x * y
+		na += x * x
+		nb += y * y
+	}
+
+	if na == 0 || nb == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func jaccard(a, b string) float64 {
+	words := func(s string) map[string]bool {
+		m := map[string]bool{}
+
+		for _, w := range strings.Fields(strings.ToLower(s)) {
+			m[w] = true
+		}
+
+		return m
+	}
+
+	x, y := words(a), words(b)
+
+	if len(x) == 0 || len(y) == 0 {
+		return 0
+	}
+
+	shared := 0
+
+	for w := range x {
+		if y[w] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(x)+len(y)-shared)
+}
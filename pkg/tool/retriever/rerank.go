@@ -0,0 +1,123 @@
+package retriever
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+// Reranker scores texts by their relevance to query; higher is better.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, texts []string) ([]float64, error)
+}
+
+var (
+	_ Reranker = (*ServerReranker)(nil)
+	_ Reranker = (*LLMReranker)(nil)
+)
+
+// ServerReranker uses the rerank capability of the Wingman server.
+type ServerReranker struct {
+	service *wingman.RerankService
+	model   string
+}
+
+func NewServerReranker(service *wingman.RerankService, model string) *ServerReranker {
+	return &ServerReranker{
+		service: service,
+		model:   model,
+	}
+}
+
+func (r *ServerReranker) Rerank(ctx context.Context, query string, texts []string) ([]float64, error) {
+	results, err := r.service.New(ctx, wingman.RerankRequest{
+		Model: r.model,
+
+		Query: query,
+		Texts: texts,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(texts))
+
+	for _, result := range results {
+		if result.Index < 0 || result.Index >= len(texts) {
+			continue
+		}
+
+		scores[result.Index] = result.Score
+	}
+
+	return scores, nil
+}
+
+// LLMReranker asks a chat model to grade the relevance of each text.
+type LLMReranker struct {
+	client *wingman.Client
+	model  string
+}
+
+func NewLLMReranker(client *wingman.Client, model string) *LLMReranker {
+	return &LLMReranker{
+		client: client,
+		model:  model,
+	}
+}
+
+func (r *LLMReranker) Rerank(ctx context.Context, query string, texts []string) ([]float64, error) {
+	var prompt strings.Builder
+
+	prompt.WriteString("Query: " + query + "\n\n")
+
+	for n, text := range texts {
+		if len(text) > 1000 {
+			text = text[:1000]
+		}
+
+		fmt.Fprintf(&prompt, "Passage %d:\n%s\n\n", n, text)
+	}
+
+	completion, err := r.client.Completions.New(ctx, wingman.CompletionRequest{
+		Model: r.model,
+
+		Messages: []wingman.Message{
+			wingman.SystemMessage("Rate how relevant each passage is to answer the query on a scale from 0 (irrelevant) to 10 (answers it fully). Reply only with a JSON array of numbers, one per passage in the given order."),
+			wingman.UserMessage(prompt.String()),
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	content := completion.Message.Text()
+
+	start := strings.Index(content, "[")
+	end := strings.LastIndex(content, "]")
+
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("invalid rerank response: %q", content)
+	}
+
+	var scores []float64
+
+	if err := json.Unmarshal([]byte(content[start:end+1]), &scores); err != nil {
+		return nil, err
+	}
+
+	if len(scores) != len(texts) {
+		return nil, fmt.Errorf("rerank returned %d scores for %d passages", len(scores), len(texts))
+	}
+
+	for n := range scores {
+		scores[n] /= 10
+	}
+
+	return scores, nil
+}
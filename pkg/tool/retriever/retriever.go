@@ -1,20 +1,127 @@
 package retriever
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/adrianliechti/wingman-cli/pkg/index"
 	"github.com/adrianliechti/wingman-cli/pkg/tool"
-	"github.com/adrianliechti/wingman/pkg/index"
 )
 
+type Index interface {
+	Query(ctx context.Context, query string, options *index.QueryOptions) ([]index.Result, error)
+
+	Sources(ctx context.Context) ([]index.Source, error)
+	Documents(ctx context.Context, source string) ([]index.Document, error)
+}
+
+const DefaultLimit = 5
+
 type Retriever struct {
-	index index.Provider
+	index Index
+
+	root string
+
+	limit      int
+	candidates int
+
+	rewriter  Rewriter
+	reranker  Reranker
+	diversity float64
+
+	mu        sync.Mutex
+	citations map[string]int
+}
+
+type Options struct {
+	// Root is the directory indexed paths are relative to
+	Root string
+
+	// Limit is the number of results returned per query
+	Limit int
+
+	// Candidates is the number of chunks fetched before reranking and
+	// diversification; defaults to four times Limit if either is enabled
+	Candidates int
+
+	// Rewriter optionally condenses the conversation into a standalone query
+	// and expands it into several queries whose results are merged
+	Rewriter Rewriter
+
+	// Reranker optionally rescores the candidates against the query
+	Reranker Reranker
+
+	// Diversity between 0 (off) and 1 penalizes results similar to already
+	// selected ones, e.g. overlapping chunks of the same passage
+	Diversity float64
+}
+
+// Result is a retrieved chunk along with the information needed to cite it.
+type Result struct {
+	Citation int `json:"citation"`
+
+	Source string `json:"source"`
+	URL    string `json:"url,omitempty"`
+
+	Chunk int     `json:"chunk"`
+	Score float32 `json:"score"`
+
+	Lines  string `json:"lines,omitempty"`
+	Pages  string `json:"pages,omitempty"`
+	Slides string `json:"slides,omitempty"`
+
+	Symbols string `json:"symbols,omitempty"`
+
+	Commit string `json:"commit,omitempty"`
+	Author string `json:"author,omitempty"`
+	Date   string `json:"date,omitempty"`
+
+	Content string `json:"content"`
 }
 
-func New(index index.Provider) *Retriever {
+func New(index Index, options *Options) *Retriever {
+	if options == nil {
+		options = new(Options)
+	}
+
+	limit := options.Limit
+
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	candidates := options.Candidates
+
+	if candidates <= 0 {
+		candidates = limit
+
+		if options.Reranker != nil || options.Diversity > 0 {
+			candidates = limit * 4
+		}
+	}
+
 	return &Retriever{
 		index: index,
+
+		root: options.Root,
+
+		limit:      max(limit, 1),
+		candidates: max(candidates, limit),
+
+		rewriter:  options.Rewriter,
+		reranker:  options.Reranker,
+		diversity: min(max(options.Diversity, 0), 1),
+
+		citations: make(map[string]int),
 	}
 }
 
@@ -22,7 +129,7 @@ func (r *Retriever) Tools(ctx context.Context) ([]tool.Tool, error) {
 	tools := []tool.Tool{
 		{
 			Name:        "retrieve_documents",
-			Description: "Query the knowledge base to find relevant documents to answer questions",
+			Description: "Query the knowledge base to find relevant documents to answer questions. Each result has a citation number to reference its source",
 
 			Schema: map[string]any{
 				"type": "object",
@@ -52,26 +159,339 @@ func (r *Retriever) Tools(ctx context.Context) ([]tool.Tool, error) {
 					return nil, err
 				}
 
-				limit := 5
+				return r.Retrieve(ctx, parameters.Query)
+			},
+		},
+
+		{
+			Name:        "list_sources",
+			Description: "List all documents (files and resources) in the knowledge base with their number of chunks",
+
+			Schema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{},
+			},
+
+			Execute: func(ctx context.Context, args map[string]any) (any, error) {
+				return r.listSources(ctx)
+			},
+		},
+
+		{
+			Name:        "read_source",
+			Description: "Read the full text of a document in the knowledge base by its source path or uri",
+
+			Schema: map[string]any{
+				"type": "object",
+
+				"properties": map[string]any{
+					"source": map[string]any{
+						"type":        "string",
+						"description": "The source path or uri as returned by retrieve_documents or list_sources",
+					},
+				},
+
+				"required": []string{"source"},
+			},
+
+			Execute: func(ctx context.Context, args map[string]any) (any, error) {
+				data, err := json.Marshal(args)
+
+				if err != nil {
+					return nil, err
+				}
+
+				var parameters struct {
+					Source string `json:"source"`
+				}
+
+				if err := json.Unmarshal(data, &parameters); err != nil {
+					return nil, err
+				}
+
+				return r.readSource(ctx, parameters.Source)
+			},
+		},
+
+		{
+			Name:        "expand_chunk",
+			Description: "Read the text surrounding a retrieved chunk by including its previous and next chunks",
+
+			Schema: map[string]any{
+				"type": "object",
+
+				"properties": map[string]any{
+					"source": map[string]any{
+						"type":        "string",
+						"description": "The source path or uri of the chunk",
+					},
+
+					"chunk": map[string]any{
+						"type":        "integer",
+						"description": "The chunk number as returned by retrieve_documents",
+					},
+
+					"before": map[string]any{
+						"type":        "integer",
+						"description": "Number of previous chunks to include (default 1)",
+					},
+
+					"after": map[string]any{
+						"type":        "integer",
+						"description": "Number of next chunks to include (default 1)",
+					},
+				},
+
+				"required": []string{"source", "chunk"},
+			},
 
-				documents, err := r.index.Query(ctx, parameters.Query, &index.QueryOptions{
-					Limit: &limit,
-				})
+			Execute: func(ctx context.Context, args map[string]any) (any, error) {
+				data, err := json.Marshal(args)
 
 				if err != nil {
 					return nil, err
 				}
 
-				var texts []string
+				var parameters struct {
+					Source string `json:"source"`
+					Chunk  int    `json:"chunk"`
+
+					Before *int `json:"before"`
+					After  *int `json:"after"`
+				}
+
+				if err := json.Unmarshal(data, &parameters); err != nil {
+					return nil, err
+				}
+
+				before, after := 1, 1
+
+				if parameters.Before != nil {
+					before = *parameters.Before
+				}
 
-				for _, d := range documents {
-					texts = append(texts, d.Content)
+				if parameters.After != nil {
+					after = *parameters.After
 				}
 
-				return texts, nil
+				return r.expandChunk(ctx, parameters.Source, parameters.Chunk, before, after)
 			},
 		},
 	}
 
 	return tools, nil
 }
+
+// Retrieve returns the chunks most relevant to query, as found by the
+// retrieve_documents tool.
+func (r *Retriever) Retrieve(ctx context.Context, query string) ([]Result, error) {
+	documents, err := r.retrieve(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+
+	for _, d := range documents {
+		results = append(results, r.result(d))
+	}
+
+	return results, nil
+}
+
+func (r *Retriever) retrieve(ctx context.Context, query string) ([]index.Result, error) {
+	queries := []string{query}
+
+	// fall back to the query as given if it cannot be rewritten
+	if r.rewriter != nil {
+		if rewritten, err := r.rewriter.Rewrite(ctx, query, tool.Messages(ctx)); err == nil && len(rewritten) > 0 {
+			queries = rewritten
+		}
+	}
+
+	results, err := r.query(ctx, queries)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query = queries[0]
+
+	if r.reranker != nil && len(results) > 0 {
+		texts := make([]string, len(results))
+
+		for n, result := range results {
+			texts[n] = result.Content
+		}
+
+		// keep the vector ranking if the reranker is unavailable
+		if scores, err := r.reranker.Rerank(ctx, query, texts); err == nil {
+			for n := range results {
+				results[n].Score = float32(scores[n])
+			}
+
+			slices.SortStableFunc(results, func(a, b index.Result) int {
+				return cmp.Compare(b.Score, a.Score)
+			})
+		}
+	}
+
+	if r.diversity > 0 {
+		return mmr(results, r.limit, 1-r.diversity), nil
+	}
+
+	if len(results) > r.limit {
+		results = results[:r.limit]
+	}
+
+	return results, nil
+}
+
+// query runs queries concurrently and merges their results, keeping the best
+// score of chunks found by several queries.
+func (r *Retriever) query(ctx context.Context, queries []string) ([]index.Result, error) {
+	limit := r.candidates
+
+	var wg sync.WaitGroup
+
+	results := make([][]index.Result, len(queries))
+	errs := make([]error, len(queries))
+
+	for n, q := range queries {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			results[n], errs[n] = r.index.Query(ctx, q, &index.QueryOptions{
+				Limit: &limit,
+			})
+		}()
+	}
+
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	if len(results) == 1 {
+		return results[0], nil
+	}
+
+	var merged []index.Result
+
+	seen := make(map[string]int)
+
+	for _, list := range results {
+		for _, result := range list {
+			if n, ok := seen[result.ID]; ok {
+				merged[n].Score = max(merged[n].Score, result.Score)
+				continue
+			}
+
+			seen[result.ID] = len(merged)
+			merged = append(merged, result)
+		}
+	}
+
+	slices.SortStableFunc(merged, func(a, b index.Result) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+
+	return merged, nil
+}
+
+func (r *Retriever) result(d index.Result) Result {
+	source := d.Source
+
+	if source == "" {
+		source = d.Metadata["path"]
+	}
+
+	if source == "" {
+		source = d.Metadata["uri"]
+	}
+
+	chunk, _ := strconv.Atoi(d.Metadata["index"])
+
+	return Result{
+		Citation: r.citation(source, chunk),
+
+		Source: source,
+		URL:    r.sourceURL(source),
+
+		Chunk: chunk,
+		Score: d.Score,
+
+		Lines:  span(d.Metadata["line_start"], d.Metadata["line_end"]),
+		Pages:  span(d.Metadata["page_start"], d.Metadata["page_end"]),
+		Slides: span(d.Metadata["slide_start"], d.Metadata["slide_end"]),
+
+		Symbols: d.Metadata["symbols"],
+
+		Commit: d.Metadata["commit"],
+		Author: d.Metadata["author"],
+		Date:   d.Metadata["date"],
+
+		Content: d.Content,
+	}
+}
+
+// citation returns a number that stays stable for a chunk across queries,
+// so answers spanning several retrievals can reference sources consistently.
+func (r *Retriever) citation(source string, chunk int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := fmt.Sprintf("%s#%d", source, chunk)
+
+	if n, ok := r.citations[key]; ok {
+		return n
+	}
+
+	n := len(r.citations) + 1
+	r.citations[key] = n
+
+	return n
+}
+
+func (r *Retriever) sourceURL(source string) string {
+	if !strings.HasPrefix(source, "/") {
+		if u, err := url.Parse(source); err == nil && u.Scheme != "" {
+			return source
+		}
+
+		return ""
+	}
+
+	if r.root == "" {
+		return ""
+	}
+
+	path := filepath.Join(r.root, filepath.FromSlash(source))
+
+	u := url.URL{
+		Scheme: "file",
+		Path:   filepath.ToSlash(path),
+	}
+
+	return u.String()
+}
+
+func span(start, end string) string {
+	if start == "" {
+		return ""
+	}
+
+	if end == "" || end == start {
+		return start
+	}
+
+	return start + "-" + end
+}
@@ -0,0 +1,184 @@
+package retriever
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
+)
+
+// Rewriter turns a query, asked within a conversation, into one or more
+// queries to run against the index. The first query is the standalone
+// version of the original one.
+type Rewriter interface {
+	Rewrite(ctx context.Context, query string, history []wingman.Message) ([]string, error)
+}
+
+var _ Rewriter = (*LLMRewriter)(nil)
+
+const maxHistoryMessages = 6
+
+// LLMRewriter uses a chat model to condense the conversation into a
+// standalone query and optionally expand it.
+type LLMRewriter struct {
+	client *wingman.Client
+	model  string
+
+	queries int
+	hyde    bool
+}
+
+type RewriterOptions struct {
+	// Queries is the number of additional sub-queries to generate
+	Queries int
+
+	// HyDE adds a hypothetical answer passage, which often embeds closer to
+	// the relevant chunks than the question itself
+	HyDE bool
+}
+
+func NewLLMRewriter(client *wingman.Client, model string, options *RewriterOptions) *LLMRewriter {
+	if options == nil {
+		options = new(RewriterOptions)
+	}
+
+	return &LLMRewriter{
+		client: client,
+		model:  model,
+
+		queries: max(options.Queries, 0),
+		hyde:    options.HyDE,
+	}
+}
+
+func (r *LLMRewriter) Rewrite(ctx context.Context, query string, history []wingman.Message) ([]string, error) {
+	transcript := conversation(history)
+
+	if transcript == "" && r.queries == 0 && !r.hyde {
+		return []string{query}, nil
+	}
+
+	var instructions strings.Builder
+
+	instructions.WriteString("You prepare search queries for a document retrieval system. Reply only with a JSON object with these fields:\n")
+	instructions.WriteString("- \"query\": the query rewritten as a standalone question, resolving references to the conversation; keep it unchanged if it is already standalone\n")
+
+	if r.queries > 0 {
+		fmt.Fprintf(&instructions, "- \"queries\": an array of %d different search queries covering other phrasings or aspects of the question\n", r.queries)
+	}
+
+	if r.hyde {
+		instructions.WriteString("- \"passage\": a short passage, as it might appear in a document, that answers the question\n")
+	}
+
+	var prompt strings.Builder
+
+	if transcript != "" {
+		prompt.WriteString("Conversation:\n" + transcript + "\n")
+	}
+
+	prompt.WriteString("Query: " + query)
+
+	completion, err := r.client.Completions.New(ctx, wingman.CompletionRequest{
+		Model: r.model,
+
+		Messages: []wingman.Message{
+			wingman.SystemMessage(instructions.String()),
+			wingman.UserMessage(prompt.String()),
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	content := completion.Message.Text()
+
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("invalid rewrite response: %q", content)
+	}
+
+	var result struct {
+		Query   string   `json:"query"`
+		Queries []string `json:"queries"`
+		Passage string   `json:"passage"`
+	}
+
+	if err := json.Unmarshal([]byte(content[start:end+1]), &result); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(result.Query) == "" {
+		result.Query = query
+	}
+
+	queries := []string{result.Query}
+
+	if len(result.Queries) > r.queries {
+		result.Queries = result.Queries[:r.queries]
+	}
+
+	for _, q := range append(result.Queries, result.Passage) {
+		q = strings.TrimSpace(q)
+
+		if q == "" || containsFold(queries, q) {
+			continue
+		}
+
+		queries = append(queries, q)
+	}
+
+	return queries, nil
+}
+
+// conversation renders the latest user and assistant messages of history,
+// leaving out system prompts and tool calls.
+func conversation(history []wingman.Message) string {
+	var lines []string
+
+	for _, m := range history {
+		role := string(m.Role)
+
+		if role != "user" && role != "assistant" {
+			continue
+		}
+
+		text := strings.TrimSpace(m.Text())
+
+		if text == "" {
+			continue
+		}
+
+		if len(text) > 1000 {
+			text = text[:1000] + "..."
+		}
+
+		lines = append(lines, role+": "+text)
+	}
+
+	if len(lines) > maxHistoryMessages {
+		lines = lines[len(lines)-maxHistoryMessages:]
+	}
+
+	// the latest user message is the question that triggered the query
+	if len(lines) <= 1 {
+		return ""
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,238 @@
+package retriever
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/adrianliechti/wingman-cli/pkg/index"
+	"github.com/adrianliechti/wingman-cli/pkg/resource"
+)
+
+// maxSourceLength caps the text returned by read_source to keep it within
+// the context window of the model.
+const maxSourceLength = 40000
+
+type SourceInfo struct {
+	Source string `json:"source"`
+	URL    string `json:"url,omitempty"`
+
+	Chunks int `json:"chunks"`
+}
+
+type Passage struct {
+	Citation int `json:"citation,omitempty"`
+
+	Source string `json:"source"`
+	URL    string `json:"url,omitempty"`
+
+	Chunks string `json:"chunks"`
+
+	Lines  string `json:"lines,omitempty"`
+	Pages  string `json:"pages,omitempty"`
+	Slides string `json:"slides,omitempty"`
+
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+func (r *Retriever) listSources(ctx context.Context) ([]SourceInfo, error) {
+	sources, err := r.index.Sources(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]SourceInfo, 0, len(sources))
+
+	for _, s := range sources {
+		result = append(result, SourceInfo{
+			Source: s.Name,
+			URL:    r.sourceURL(s.Name),
+
+			Chunks: s.Chunks,
+		})
+	}
+
+	return result, nil
+}
+
+func (r *Retriever) readSource(ctx context.Context, source string) (*Passage, error) {
+	documents, err := r.documents(ctx, source)
+
+	if err != nil {
+		return nil, err
+	}
+
+	passage := r.passage(source, documents)
+
+	if len(passage.Content) > maxSourceLength {
+		passage.Content = passage.Content[:maxSourceLength]
+		passage.Truncated = true
+	}
+
+	return passage, nil
+}
+
+func (r *Retriever) expandChunk(ctx context.Context, source string, chunk, before, after int) (*Passage, error) {
+	documents, err := r.documents(ctx, source)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []index.Document
+
+	for _, d := range documents {
+		n, _ := strconv.Atoi(d.Metadata["index"])
+
+		if n < chunk-max(before, 0) || n > chunk+max(after, 0) {
+			continue
+		}
+
+		selected = append(selected, d)
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("chunk %d not found in %s", chunk, source)
+	}
+
+	passage := r.passage(source, selected)
+	passage.Citation = r.citation(source, chunk)
+
+	return passage, nil
+}
+
+func (r *Retriever) documents(ctx context.Context, source string) ([]index.Document, error) {
+	source = strings.TrimSpace(source)
+
+	if source == "" {
+		return nil, errors.New("source is required")
+	}
+
+	documents, err := r.index.Documents(ctx, source)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(documents) == 0 {
+		return nil, errors.New("source not found: " + source)
+	}
+
+	return documents, nil
+}
+
+// passage joins consecutive chunks into one text, dropping the overlap
+// the segmenter adds between neighbors.
+func (r *Retriever) passage(source string, documents []index.Document) *Passage {
+	first := documents[0].Metadata
+	last := documents[len(documents)-1].Metadata
+
+	var content string
+
+	for _, d := range documents {
+		content = joinOverlap(content, d.Content)
+	}
+
+	return &Passage{
+		Source: source,
+		URL:    r.sourceURL(source),
+
+		Chunks: span(first["index"], last["index"]),
+
+		Lines:  span(first["line_start"], last["line_end"]),
+		Pages:  span(first["page_start"], last["page_end"]),
+		Slides: span(first["slide_start"], last["slide_end"]),
+
+		Content: content,
+	}
+}
+
+// joinOverlap appends next to text, skipping the longest prefix of next that
+// text already ends with. Overlaps shorter than minOverlap are treated as
+// coincidence and the texts are joined with a blank line.
+func joinOverlap(text, next string) string {
+	const minOverlap = 16
+
+	if text == "" {
+		return next
+	}
+
+	if len(next) >= minOverlap {
+		tail := text[max(0, len(text)-len(next)):]
+		probe := next[:minOverlap]
+
+		for from := 0; from < len(tail); {
+			p := strings.Index(tail[from:], probe)
+
+			if p < 0 {
+				break
+			}
+
+			p += from
+
+			if strings.HasPrefix(next, tail[p:]) {
+				return text + next[len(tail)-p:]
+			}
+
+			from = p + 1
+		}
+	}
+
+	return text + "\n\n" + next
+}
+
+// Resources returns a resource per indexed source holding the text of all
+// its chunks. Sources are addressed by their url, or by a wingman://index/
+// uri if they have none.
+func (r *Retriever) Resources(ctx context.Context) ([]resource.Resource, error) {
+	sources, err := r.index.Sources(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []resource.Resource
+
+	seen := make(map[string]bool)
+
+	for _, s := range sources {
+		// the same source may be indexed in several collections
+		if seen[s.Name] {
+			continue
+		}
+
+		seen[s.Name] = true
+
+		name := s.Name
+		uri := r.sourceURL(name)
+
+		if uri == "" {
+			uri = "wingman://index/" + strings.TrimPrefix(name, "/")
+		}
+
+		result = append(result, resource.Resource{
+			URI: uri,
+
+			Name:        name,
+			Description: fmt.Sprintf("%d chunks", s.Chunks),
+
+			ContentType: "text/plain",
+
+			Content: func(ctx context.Context) ([]byte, error) {
+				documents, err := r.documents(ctx, name)
+
+				if err != nil {
+					return nil, err
+				}
+
+				return []byte(r.passage(name, documents).Content), nil
+			},
+		})
+	}
+
+	return result, nil
+}
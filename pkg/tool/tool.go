@@ -2,6 +2,8 @@ package tool
 
 import (
 	"context"
+
+	wingman "github.com/adrianliechti/wingman/pkg/client"
 )
 
 type Provider interface {
@@ -18,3 +20,16 @@ type Tool struct {
 	Schema  Schema
 	Execute ExecuteFn
 }
+
+type messagesKey struct{}
+
+// WithMessages attaches the conversation leading to a tool call to ctx.
+func WithMessages(ctx context.Context, messages []wingman.Message) context.Context {
+	return context.WithValue(ctx, messagesKey{}, messages)
+}
+
+// Messages returns the conversation attached by WithMessages, if any.
+func Messages(ctx context.Context) []wingman.Message {
+	messages, _ := ctx.Value(messagesKey{}).([]wingman.Message)
+	return messages
+}